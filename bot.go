@@ -0,0 +1,271 @@
+package ga4m
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// TrafficTypeParam is the parameter GA uses to classify traffic, e.g. for data filters
+	TrafficTypeParam = "traffic_type"
+
+	// BotTrafficType is the traffic_type value added to events from bots under BotTag
+	BotTrafficType = "bot"
+)
+
+// BotPolicy is how an AnalyticsClient handles events sent with a context classified as bot traffic,
+// see BotMiddleware.
+type BotPolicy int
+
+const (
+	// BotAllow sends bot traffic like any other. This is the default.
+	BotAllow BotPolicy = iota
+
+	// BotDrop silently drops events from bot traffic.
+	BotDrop
+
+	// BotTag sends events from bot traffic with the traffic_type parameter set to "bot", unless already set,
+	// so they can be excluded with a GA data filter.
+	BotTag
+)
+
+//go:embed bot_rules.json
+var defaultBotRules []byte
+
+// BotRules are the rules a BotFilter classifies requests with. They are usually loaded from JSON, e.g.
+//
+//	{"user_agents": ["bot", "curl/"], "headers": [{"name": "From"}], "cidrs": ["66.249.64.0/19"]}
+type BotRules struct {
+	// UserAgents are case-insensitive substrings of bot User-Agent headers.
+	UserAgents []string `json:"user_agents"`
+
+	// Headers are header signatures sent by bots, such as link preview fetchers.
+	Headers []BotHeaderRule `json:"headers"`
+
+	// CIDRs are the IP ranges bots send requests from, e.g. "66.249.64.0/19".
+	CIDRs []string `json:"cidrs"`
+}
+
+// BotHeaderRule matches requests with a header, optionally only if its value contains a case-insensitive substring.
+type BotHeaderRule struct {
+	Name     string `json:"name"`
+	Contains string `json:"contains,omitempty"`
+}
+
+// DefaultBotRules returns the rules embedded in the package, covering common crawlers, HTTP libraries,
+// link unfurlers, uptime checkers and load balancer health checks.
+func DefaultBotRules() BotRules {
+	rules, err := ParseBotRules(defaultBotRules)
+	if err != nil {
+		panic(fmt.Sprintf("ga4m: invalid embedded bot rules: %v", err))
+	}
+	return rules
+}
+
+// ParseBotRules parses BotRules from JSON, rejecting unknown fields.
+func ParseBotRules(data []byte) (BotRules, error) {
+	var rules BotRules
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return BotRules{}, fmt.Errorf("failed to parse bot rules: %w", err)
+	}
+	return rules, nil
+}
+
+// LoadBotRules reads BotRules from a JSON file.
+func LoadBotRules(path string) (BotRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return BotRules{}, fmt.Errorf("failed to read bot rules: %w", err)
+	}
+	return ParseBotRules(data)
+}
+
+// Merge returns the rules combined with other, e.g. to extend DefaultBotRules with your own.
+func (r BotRules) Merge(other BotRules) BotRules {
+	return BotRules{
+		UserAgents: append(slices.Clone(r.UserAgents), other.UserAgents...),
+		Headers:    append(slices.Clone(r.Headers), other.Headers...),
+		CIDRs:      append(slices.Clone(r.CIDRs), other.CIDRs...),
+	}
+}
+
+// BotVerdict is the result of classifying a request.
+type BotVerdict struct {
+	Bot bool
+
+	// Reason is the rule that matched, e.g. "user_agent:curl/", "header:From", "cidr:66.249.64.0/19"
+	// or "empty_user_agent".
+	Reason string
+}
+
+// BotFilter classifies requests as bot traffic. Its rules can be replaced at runtime with Update.
+// It is safe for concurrent use.
+type BotFilter struct {
+	// TrustedProxies are the proxies whose X-Forwarded-For header is used for the client IP.
+	TrustedProxies []netip.Prefix
+
+	rules atomic.Pointer[compiledBotRules]
+}
+
+type compiledBotRules struct {
+	userAgents []string
+	headers    []BotHeaderRule
+	prefixes   []netip.Prefix
+}
+
+// NewBotFilter creates a new BotFilter with the rules merged, or DefaultBotRules if none are given.
+func NewBotFilter(rules ...BotRules) (*BotFilter, error) {
+	f := &BotFilter{}
+	if len(rules) == 0 {
+		rules = []BotRules{DefaultBotRules()}
+	}
+	var merged BotRules
+	for _, r := range rules {
+		merged = merged.Merge(r)
+	}
+	if err := f.Update(merged); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Update atomically replaces the filter's rules, e.g. after reloading them with LoadBotRules.
+func (f *BotFilter) Update(rules BotRules) error {
+	compiled := &compiledBotRules{}
+	for _, ua := range rules.UserAgents {
+		if ua = strings.ToLower(strings.TrimSpace(ua)); ua != "" {
+			compiled.userAgents = append(compiled.userAgents, ua)
+		}
+	}
+	for _, header := range rules.Headers {
+		if header.Name == "" {
+			return fmt.Errorf("bot header rule must have a name")
+		}
+		compiled.headers = append(compiled.headers, BotHeaderRule{
+			Name:     http.CanonicalHeaderKey(header.Name),
+			Contains: strings.ToLower(header.Contains),
+		})
+	}
+	for _, cidr := range rules.CIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid bot CIDR %q: %w", cidr, err)
+		}
+		compiled.prefixes = append(compiled.prefixes, prefix.Masked())
+	}
+	f.rules.Store(compiled)
+	return nil
+}
+
+// Classify classifies a request by its User-Agent, headers and client IP.
+func (f *BotFilter) Classify(r *http.Request) BotVerdict {
+	rules := f.rules.Load()
+	if rules == nil {
+		return BotVerdict{}
+	}
+
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return BotVerdict{Bot: true, Reason: "empty_user_agent"}
+	}
+	for _, pattern := range rules.userAgents {
+		if strings.Contains(ua, pattern) {
+			return BotVerdict{Bot: true, Reason: "user_agent:" + pattern}
+		}
+	}
+
+	for _, header := range rules.headers {
+		values := r.Header.Values(header.Name)
+		for _, value := range values {
+			if header.Contains == "" || strings.Contains(strings.ToLower(value), header.Contains) {
+				return BotVerdict{Bot: true, Reason: "header:" + header.Name}
+			}
+		}
+	}
+
+	if len(rules.prefixes) > 0 {
+		if addr, ok := f.clientIP(r); ok {
+			for _, prefix := range rules.prefixes {
+				if prefix.Contains(addr) {
+					return BotVerdict{Bot: true, Reason: "cidr:" + prefix.String()}
+				}
+			}
+		}
+	}
+
+	return BotVerdict{}
+}
+
+// Matcher returns a RequestMatcher matching requests that are not bot traffic, e.g. for PageViewConfig.Matchers.
+func (f *BotFilter) Matcher() RequestMatcher {
+	return func(r *http.Request) bool {
+		if verdict, ok := BotVerdictFromContext(r.Context()); ok {
+			return !verdict.Bot
+		}
+		return !f.Classify(r).Bot
+	}
+}
+
+// clientIP returns the request's client IP. When the request comes from a trusted proxy, X-Forwarded-For is
+// walked from the right, skipping trusted proxies, since the entries left of the nearest untrusted hop are
+// whatever the client sent.
+func (f *BotFilter) clientIP(r *http.Request) (netip.Addr, bool) {
+	addr, ok := parseHostAddr(r.RemoteAddr)
+	if !ok || !isTrustedProxy(r.RemoteAddr, f.TrustedProxies) {
+		return addr, ok
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(forwarded[i])
+		if entry == "" {
+			continue
+		}
+		hop, ok := parseHostAddr(entry)
+		if !ok {
+			return netip.Addr{}, false
+		}
+		addr = hop
+		if !isTrustedProxy(entry, f.TrustedProxies) {
+			break
+		}
+	}
+	return addr, true
+}
+
+// botVerdictContextKey is the context key for the BotVerdict.
+type botVerdictContextKey struct{}
+
+// NewBotVerdictContext returns a copy of ctx carrying the verdict.
+func NewBotVerdictContext(ctx context.Context, verdict BotVerdict) context.Context {
+	return context.WithValue(ctx, botVerdictContextKey{}, verdict)
+}
+
+// BotVerdictFromContext returns the verdict stored in ctx by NewBotVerdictContext or BotMiddleware, if any.
+func BotVerdictFromContext(ctx context.Context) (BotVerdict, bool) {
+	verdict, ok := ctx.Value(botVerdictContextKey{}).(BotVerdict)
+	return verdict, ok
+}
+
+// BotMiddleware classifies each request with the filter and stores the verdict in the request context, see
+// BotVerdictFromContext. Requests are never blocked; events sent with the context, e.g. by PageViewMiddleware
+// or with WithContext, are dropped or tagged according to the client's BotPolicy.
+func BotMiddleware(filter *BotFilter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewBotVerdictContext(r.Context(), filter.Classify(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package ga4m
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36"
+
+func TestBotFilter_Classify(t *testing.T) {
+	filter, err := NewBotFilter(DefaultBotRules(), BotRules{CIDRs: []string{"203.0.113.0/24"}})
+	require.NoError(t, err)
+	filter.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name           string
+		userAgent      string
+		headers        map[string]string
+		remoteAddr     string
+		expectedBot    bool
+		expectedReason string
+	}{
+		{name: "Browser", userAgent: chromeUA},
+		{name: "Googlebot", userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", expectedBot: true, expectedReason: "user_agent:bot"},
+		{name: "Curl", userAgent: "curl/8.4.0", expectedBot: true, expectedReason: "user_agent:curl/"},
+		{name: "Link Unfurler", userAgent: "facebookexternalhit/1.1", expectedBot: true, expectedReason: "user_agent:facebookexternalhit"},
+		{name: "Health Check", userAgent: "kube-probe/1.30", expectedBot: true, expectedReason: "user_agent:kube-probe"},
+		{name: "Empty User-Agent", expectedBot: true, expectedReason: "empty_user_agent"},
+		{name: "From Header", userAgent: chromeUA, headers: map[string]string{"From": "crawler@example.com"}, expectedBot: true, expectedReason: "header:From"},
+		{name: "Prefetch", userAgent: chromeUA, headers: map[string]string{"Sec-Purpose": "prefetch;prerender"}, expectedBot: true, expectedReason: "header:Sec-Purpose"},
+		{name: "Other Purpose", userAgent: chromeUA, headers: map[string]string{"X-Purpose": "navigate"}},
+		{name: "Bot CIDR", userAgent: chromeUA, remoteAddr: "203.0.113.7:4321", expectedBot: true, expectedReason: "cidr:203.0.113.0/24"},
+		{name: "Bot CIDR Behind Proxy", userAgent: chromeUA, remoteAddr: "10.1.2.3:4321", headers: map[string]string{"X-Forwarded-For": "203.0.113.7, 10.1.2.3"}, expectedBot: true, expectedReason: "cidr:203.0.113.0/24"},
+		{name: "Spoofed Leftmost Forwarded For", userAgent: chromeUA, remoteAddr: "10.1.2.3:4321", headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.7"}, expectedBot: true, expectedReason: "cidr:203.0.113.0/24"},
+		{name: "Spoofed Bot Forwarded For", userAgent: chromeUA, remoteAddr: "10.1.2.3:4321", headers: map[string]string{"X-Forwarded-For": "203.0.113.7, 198.51.100.1, 10.4.5.6"}},
+		{name: "Untrusted Forwarded For", userAgent: chromeUA, remoteAddr: "198.51.100.1:4321", headers: map[string]string{"X-Forwarded-For": "203.0.113.7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+
+			verdict := filter.Classify(req)
+			assert.Equal(t, tt.expectedBot, verdict.Bot)
+			assert.Equal(t, tt.expectedReason, verdict.Reason)
+		})
+	}
+}
+
+func TestBotFilter_Update(t *testing.T) {
+	filter, err := NewBotFilter(BotRules{UserAgents: []string{"InternalMonitor"}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "internalmonitor/1.0")
+	assert.True(t, filter.Classify(req).Bot)
+
+	require.NoError(t, filter.Update(BotRules{}))
+	assert.False(t, filter.Classify(req).Bot)
+
+	// invalid rules are rejected and the current rules kept
+	assert.Error(t, filter.Update(BotRules{CIDRs: []string{"not a cidr"}}))
+	assert.Error(t, filter.Update(BotRules{Headers: []BotHeaderRule{{Contains: "x"}}}))
+}
+
+func TestLoadBotRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"user_agents": ["acme-checker"], "cidrs": ["192.0.2.0/24"]}`), 0o600))
+
+	rules, err := LoadBotRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme-checker"}, rules.UserAgents)
+	assert.Equal(t, []string{"192.0.2.0/24"}, rules.CIDRs)
+
+	_, err = ParseBotRules([]byte(`{"user_agent": ["typo"]}`))
+	assert.Error(t, err)
+}
+
+func TestDefaultBotRules(t *testing.T) {
+	rules := DefaultBotRules()
+	assert.NotEmpty(t, rules.UserAgents)
+	assert.NotEmpty(t, rules.Headers)
+
+	_, err := NewBotFilter(rules)
+	assert.NoError(t, err)
+}
+
+func TestBotMiddleware_Policy(t *testing.T) {
+	filter, err := NewBotFilter()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                string
+		policy              BotPolicy
+		expectedEvents      int
+		expectedTrafficType string
+	}{
+		{"Allow", BotAllow, 1, ""},
+		{"Drop", BotDrop, 0, ""},
+		{"Tag", BotTag, 1, BotTrafficType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, payloads := recordingClient(t)
+			client.SetBotPolicy(tt.policy)
+			handler := BotMiddleware(filter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				verdict, ok := BotVerdictFromContext(r.Context())
+				assert.True(t, ok)
+				assert.True(t, verdict.Bot)
+				err := client.SendEvent(Session{ClientID: "71807069.1731019235"}, "page_view", nil, WithContext(r.Context()))
+				assert.NoError(t, err)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", "curl/8.4.0")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			sent := payloads()
+			require.Len(t, sent, tt.expectedEvents)
+			if tt.expectedEvents > 0 {
+				assert.Equal(t, tt.expectedTrafficType, sent[0].Events[0].Params[TrafficTypeParam])
+			}
+		})
+	}
+}
+
+func TestBotFilter_Matcher(t *testing.T) {
+	filter, err := NewBotFilter()
+	require.NoError(t, err)
+	client, payloads := recordingClient(t)
+	matchers := append(DefaultPageViewMatchers(), filter.Matcher())
+	handler := PageViewMiddleware(PageViewConfig{Client: client, Matchers: matchers})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, ua := range []string{chromeUA, "Slackbot-LinkExpanding 1.0"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", ua)
+		req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Eventually(t, func() bool { return len(payloads()) == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, payloads(), 1)
+
+	// a verdict already in the context is used
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(NewBotVerdictContext(context.Background(), BotVerdict{Bot: true}))
+	req.Header.Set("User-Agent", chromeUA)
+	assert.False(t, filter.Matcher()(req))
+}
+
+func BenchmarkBotFilter_Classify(b *testing.B) {
+	filter, err := NewBotFilter()
+	require.NoError(b, err)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", chromeUA)
+
+	b.ResetTimer()
+	for range b.N {
+		filter.Classify(req)
+	}
+}
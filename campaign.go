@@ -0,0 +1,186 @@
+package ga4m
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// CampaignDetailsEvent is the name of the event GA uses to record campaign attribution
+	CampaignDetailsEvent = "campaign_details"
+
+	// MediumOrganic is the medium of traffic referred by a search engine
+	MediumOrganic = "organic"
+
+	// MediumReferral is the medium of traffic referred by another website
+	MediumReferral = "referral"
+
+	// MediumSocial is the medium of traffic referred by a social network
+	MediumSocial = "social"
+
+	// MediumCPC is the medium of auto-tagged Google Ads traffic
+	MediumCPC = "cpc"
+)
+
+// searchEngines maps a domain label to the source name of a search engine (e.g. "google" in www.google.co.uk).
+var searchEngines = map[string]string{
+	"google":     "google",
+	"bing":       "bing",
+	"yahoo":      "yahoo",
+	"duckduckgo": "duckduckgo",
+	"baidu":      "baidu",
+	"yandex":     "yandex",
+	"ecosia":     "ecosia",
+	"naver":      "naver",
+	"seznam":     "seznam",
+	"ask":        "ask",
+	"aol":        "aol",
+	"qwant":      "qwant",
+	"startpage":  "startpage",
+}
+
+// socialNetworks maps a domain, and any of its subdomains, to the source name of a social network.
+var socialNetworks = map[string]string{
+	"facebook.com":  "facebook",
+	"fb.com":        "facebook",
+	"instagram.com": "instagram",
+	"twitter.com":   "twitter",
+	"t.co":          "twitter",
+	"x.com":         "twitter",
+	"linkedin.com":  "linkedin",
+	"lnkd.in":       "linkedin",
+	"reddit.com":    "reddit",
+	"pinterest.com": "pinterest",
+	"youtube.com":   "youtube",
+	"tiktok.com":    "tiktok",
+	"threads.net":   "threads",
+	"bsky.app":      "bluesky",
+	"snapchat.com":  "snapchat",
+}
+
+// Campaign is the traffic source attribution of a request, from UTM parameters, ad click IDs or the referrer.
+type Campaign struct {
+	Source  string // utm_source, or the inferred source (e.g., "google")
+	Medium  string // utm_medium, or the inferred medium (e.g., "organic")
+	Name    string // utm_campaign
+	Term    string // utm_term
+	Content string // utm_content
+	ID      string // utm_id
+
+	// Ad click identifiers
+	GCLID  string // Google Ads click ID
+	DCLID  string // Display & Video 360 click ID
+	GBRAID string // Google Ads app-to-web click ID (iOS)
+	WBRAID string // Google Ads web-to-app click ID (iOS)
+}
+
+// ParseCampaignFromRequest extracts campaign attribution from an HTTP request. UTM parameters in the URL take
+// precedence; auto-tagged Google Ads and Display & Video 360 clicks are attributed to google / cpc; otherwise the
+// Referer header is classified as organic search, social or referral traffic, filling only the source and medium
+// not set by UTM parameters. Referrers from the request's own host are ignored.
+func ParseCampaignFromRequest(r *http.Request) Campaign {
+	query := r.URL.Query()
+	campaign := Campaign{
+		Source:  query.Get("utm_source"),
+		Medium:  query.Get("utm_medium"),
+		Name:    query.Get("utm_campaign"),
+		Term:    query.Get("utm_term"),
+		Content: query.Get("utm_content"),
+		ID:      query.Get("utm_id"),
+		GCLID:   query.Get("gclid"),
+		DCLID:   query.Get("dclid"),
+		GBRAID:  query.Get("gbraid"),
+		WBRAID:  query.Get("wbraid"),
+	}
+	if campaign.Source != "" {
+		return campaign
+	}
+
+	// auto-tagged Google Ads and Display & Video 360 clicks
+	if campaign.GCLID != "" || campaign.DCLID != "" || campaign.GBRAID != "" || campaign.WBRAID != "" {
+		campaign.Source = "google"
+		if campaign.Medium == "" {
+			campaign.Medium = MediumCPC
+		}
+		return campaign
+	}
+
+	// classify the referrer
+	referrer, err := url.Parse(r.Referer())
+	if err != nil || referrer.Hostname() == "" {
+		return campaign
+	}
+	host := strings.ToLower(referrer.Hostname())
+	if host == strings.ToLower(hostWithoutPort(r.Host)) {
+		return campaign
+	}
+	source, medium := classifyReferrer(host)
+	campaign.Source = source
+	if campaign.Medium == "" {
+		campaign.Medium = medium
+		if medium == MediumOrganic && campaign.Term == "" {
+			campaign.Term = referrer.Query().Get("q")
+		}
+	}
+	return campaign
+}
+
+// classifyReferrer returns the source and medium for a referring host.
+func classifyReferrer(host string) (source, medium string) {
+	for domain, name := range socialNetworks {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return name, MediumSocial
+		}
+	}
+	for _, label := range strings.Split(host, ".") {
+		if name, ok := searchEngines[label]; ok {
+			return name, MediumOrganic
+		}
+	}
+	return strings.TrimPrefix(host, "www."), MediumReferral
+}
+
+// hostWithoutPort strips the port from a host:port string.
+func hostWithoutPort(host string) string {
+	if u, err := url.Parse("//" + host); err == nil {
+		return u.Hostname()
+	}
+	return host
+}
+
+// IsEmpty reports whether no attribution was found.
+func (c Campaign) IsEmpty() bool {
+	return c == Campaign{}
+}
+
+// Params returns the campaign as event parameters, ready to merge into a page_view passed to SendEvent.
+// Empty fields are omitted and values are truncated to the maximum parameter length.
+func (c Campaign) Params() map[string]string {
+	params := make(map[string]string)
+	for name, value := range map[string]string{
+		"source":      c.Source,
+		"medium":      c.Medium,
+		"campaign":    c.Name,
+		"term":        c.Term,
+		"content":     c.Content,
+		"campaign_id": c.ID,
+		"gclid":       c.GCLID,
+		"dclid":       c.DCLID,
+		"gbraid":      c.GBRAID,
+		"wbraid":      c.WBRAID,
+	} {
+		if value != "" {
+			params[name] = truncateString(value, maxParamValueLength)
+		}
+	}
+	return params
+}
+
+// Event returns the campaign as a campaign_details event.
+func (c Campaign) Event() EventParams {
+	return EventParams{
+		Name:   CampaignDetailsEvent,
+		Params: c.Params(),
+	}
+}
@@ -0,0 +1,138 @@
+package ga4m
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCampaignFromRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		referrer string
+		expected Campaign
+	}{
+		{
+			name: "UTM Parameters",
+			url:  "/landing?utm_source=newsletter&utm_medium=email&utm_campaign=spring_sale&utm_term=shoes&utm_content=header&utm_id=abc123",
+			expected: Campaign{
+				Source:  "newsletter",
+				Medium:  "email",
+				Name:    "spring_sale",
+				Term:    "shoes",
+				Content: "header",
+				ID:      "abc123",
+			},
+		},
+		{
+			name:     "UTM Parameters Win Over Referrer",
+			url:      "/landing?utm_source=partner&utm_medium=affiliate",
+			referrer: "https://www.google.com/",
+			expected: Campaign{Source: "partner", Medium: "affiliate"},
+		},
+		{
+			name:     "Google Ads Click",
+			url:      "/landing?gclid=Cj0KCQ&utm_campaign=brand",
+			referrer: "https://www.google.com/",
+			expected: Campaign{Source: "google", Medium: MediumCPC, Name: "brand", GCLID: "Cj0KCQ"},
+		},
+		{
+			name:     "Click IDs Without Source",
+			url:      "/landing?dclid=d1&gbraid=g1&wbraid=w1",
+			expected: Campaign{Source: "google", Medium: MediumCPC, DCLID: "d1", GBRAID: "g1", WBRAID: "w1"},
+		},
+		{
+			name:     "Display & Video 360 Click",
+			url:      "/landing?dclid=d1",
+			expected: Campaign{Source: "google", Medium: MediumCPC, DCLID: "d1"},
+		},
+		{
+			name:     "UTM Medium Without Source",
+			url:      "/landing?utm_medium=email",
+			referrer: "https://www.google.com/search?q=shoes",
+			expected: Campaign{Source: "google", Medium: "email"},
+		},
+		{
+			name:     "Organic Search",
+			url:      "/",
+			referrer: "https://www.google.co.uk/search?q=running+shoes",
+			expected: Campaign{Source: "google", Medium: MediumOrganic, Term: "running shoes"},
+		},
+		{
+			name:     "Organic Search Without Query",
+			url:      "/",
+			referrer: "https://duckduckgo.com/",
+			expected: Campaign{Source: "duckduckgo", Medium: MediumOrganic},
+		},
+		{
+			name:     "Social",
+			url:      "/",
+			referrer: "https://l.facebook.com/l.php?u=https%3A%2F%2Fexample.com",
+			expected: Campaign{Source: "facebook", Medium: MediumSocial},
+		},
+		{
+			name:     "Social Short Link",
+			url:      "/",
+			referrer: "https://t.co/abc",
+			expected: Campaign{Source: "twitter", Medium: MediumSocial},
+		},
+		{
+			name:     "Referral",
+			url:      "/",
+			referrer: "https://www.partner-blog.com/post/1",
+			expected: Campaign{Source: "partner-blog.com", Medium: MediumReferral},
+		},
+		{
+			name:     "Internal Referrer",
+			url:      "/",
+			referrer: "https://example.com/previous",
+			expected: Campaign{},
+		},
+		{
+			name:     "Direct",
+			url:      "/",
+			expected: Campaign{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "https://example.com"+tt.url, nil)
+			if tt.referrer != "" {
+				req.Header.Set("Referer", tt.referrer)
+			}
+			assert.Equal(t, tt.expected, ParseCampaignFromRequest(req))
+		})
+	}
+}
+
+func TestCampaign_Params(t *testing.T) {
+	campaign := Campaign{
+		Source: "newsletter",
+		Medium: "email",
+		Name:   strings.Repeat("x", 150),
+		ID:     "abc123",
+		GCLID:  "Cj0KCQ",
+	}
+
+	assert.Equal(t, map[string]string{
+		"source":      "newsletter",
+		"medium":      "email",
+		"campaign":    strings.Repeat("x", maxParamValueLength),
+		"campaign_id": "abc123",
+		"gclid":       "Cj0KCQ",
+	}, campaign.Params())
+	assert.NoError(t, validateParams(campaign.Params()))
+
+	event := campaign.Event()
+	assert.Equal(t, CampaignDetailsEvent, event.Name)
+	assert.Equal(t, campaign.Params(), event.Params)
+
+	assert.False(t, campaign.IsEmpty())
+	assert.True(t, Campaign{}.IsEmpty())
+	assert.Empty(t, Campaign{}.Params())
+}
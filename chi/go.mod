@@ -0,0 +1,19 @@
+module github.com/agentstation/ga4m/chi
+
+go 1.23.1
+
+require (
+	github.com/agentstation/ga4m v0.2.0
+	github.com/go-chi/chi/v5 v5.1.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The replace directive is for development in this repository and is ignored by consumers of the module,
+// who get the required core version. See Releasing in the README.
+replace github.com/agentstation/ga4m => ../
@@ -0,0 +1,75 @@
+// Package ga4mchi provides chi middleware for the ga4m Google Analytics 4 client.
+package ga4mchi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/agentstation/ga4m"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Middleware extracts user Google Analytics session data from cookies and
+// stores it in the request context for later use, see GetSession
+func Middleware() func(http.Handler) http.Handler {
+	return ga4m.GoogleAnalyticsCookieMiddleware()
+}
+
+// MiddlewareWithConfig returns a Middleware with config
+func MiddlewareWithConfig(config ga4m.CookieMiddlewareConfig) func(http.Handler) http.Handler {
+	return ga4m.GoogleAnalyticsCookieMiddlewareWithConfig(config)
+}
+
+// GetSession returns the Google Analytics session stored in the request context by Middleware.
+func GetSession(r *http.Request) (ga4m.Session, bool) {
+	return ga4m.SessionFromContext(r.Context())
+}
+
+// ParseSession returns the Google Analytics session stored in the request context by Middleware,
+// or parses it from the request cookies if the middleware is not in use.
+func ParseSession(r *http.Request) ga4m.Session {
+	if session, ok := GetSession(r); ok {
+		return session
+	}
+	return ga4m.ParseSessionFromRequest(r)
+}
+
+// PageViewConfig configures the chi page_view middleware.
+type PageViewConfig struct {
+	// Skipper defines a function to skip the middleware, in addition to PageViewConfig.Matchers.
+	Skipper func(r *http.Request) bool
+
+	ga4m.PageViewConfig
+}
+
+// PageViewMiddleware sends a page_view (or PageViewConfig.EventName) event after each matching response.
+// The route pattern, such as /users/{id}, is used as the page path, and the status code and latency are added
+// as parameters. Events are sent in the background so responses are never delayed; set PageViewConfig.Sender
+// to wait for them on shutdown. Use after Middleware, or the session is parsed from the request cookies.
+func PageViewMiddleware(config PageViewConfig) func(http.Handler) http.Handler {
+	tracker := ga4m.NewPageViewTracker(config.PageViewConfig)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (config.Skipper != nil && config.Skipper(r)) || !tracker.Match(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			var route string
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			tracker.Track(r, route, status, time.Since(start))
+		})
+	}
+}
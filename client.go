@@ -1,6 +1,8 @@
 package ga4m
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 )
@@ -17,6 +19,30 @@ type AnalyticsClient struct {
 	Endpoint      string
 	DebugEndpoint string
 	HTTPClient    HTTPClient
+
+	// Sanitizer, if set, repairs invalid event names and parameters instead of rejecting the event.
+	Sanitizer *Sanitizer
+
+	// Schema, if set, is the catalog every event is validated against.
+	Schema *Schema
+
+	// SchemaMode controls whether schema violations reject the event or are only reported.
+	SchemaMode SchemaMode
+
+	// OnSchemaViolation, if set, is called with the event and its violations in SchemaWarn mode.
+	OnSchemaViolation func(event EventParams, err error)
+
+	// BotPolicy controls whether events sent with a context classified as bot traffic are sent, dropped or tagged.
+	BotPolicy BotPolicy
+
+	// Sampler, if set, sends only a fraction of the events it has rates for, chosen by client ID.
+	Sampler *Sampler
+
+	// BeforeSend hooks run in order on every payload before it is sent, and may modify or drop it.
+	BeforeSend []BeforeSendHook
+
+	// AfterSend hooks run in order with the result of every payload sent.
+	AfterSend []AfterSendHook
 }
 
 // NewClient creates a new AnalyticsClient with the provided measurement ID and API secret
@@ -30,7 +56,64 @@ func NewClient(measurementID, apiSecret string) *AnalyticsClient {
 	}
 }
 
+// New creates a new AnalyticsClient with the provided measurement ID, API secret and options.
+// Unlike NewClient, it validates the configuration and returns an error if it is invalid,
+// so misconfiguration is caught at startup rather than as rejected or dropped events.
+func New(measurementID, apiSecret string, opts ...ClientOption) (*AnalyticsClient, error) {
+	c := NewClient(measurementID, apiSecret)
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the client configuration, returning every problem found.
+func (c *AnalyticsClient) Validate() error {
+	var errs []error
+	if err := validateMeasurementID(c.MeasurementID); err != nil {
+		errs = append(errs, err)
+	}
+	if c.APISecret == "" {
+		errs = append(errs, errors.New("API secret cannot be empty"))
+	}
+	if err := validateEndpoint(c.Endpoint); err != nil {
+		errs = append(errs, fmt.Errorf("invalid endpoint: %w", err))
+	}
+	if err := validateEndpoint(c.DebugEndpoint); err != nil {
+		errs = append(errs, fmt.Errorf("invalid debug endpoint: %w", err))
+	}
+	if c.HTTPClient == nil {
+		errs = append(errs, errors.New("HTTP client cannot be nil"))
+	}
+	return errors.Join(errs...)
+}
+
 // SetHTTPClient allows setting a custom HTTP client
 func (c *AnalyticsClient) SetHTTPClient(client HTTPClient) {
 	c.HTTPClient = client
 }
+
+// SetSanitizer enables lenient sanitizing mode. Passing nil restores strict validation.
+func (c *AnalyticsClient) SetSanitizer(sanitizer *Sanitizer) {
+	c.Sanitizer = sanitizer
+}
+
+// SetSchema sets the event catalog events are validated against and how violations are handled.
+// Passing a nil schema disables schema validation.
+func (c *AnalyticsClient) SetSchema(schema *Schema, mode SchemaMode) {
+	c.Schema = schema
+	c.SchemaMode = mode
+}
+
+// SetBotPolicy sets how events sent with a context classified as bot traffic are handled, see BotMiddleware.
+func (c *AnalyticsClient) SetBotPolicy(policy BotPolicy) {
+	c.BotPolicy = policy
+}
+
+// SetSampler sets the sampler deciding which events are sent. Passing nil sends every event.
+func (c *AnalyticsClient) SetSampler(sampler *Sampler) {
+	c.Sampler = sampler
+}
@@ -0,0 +1,75 @@
+package ga4m
+
+// ClientOption configures an AnalyticsClient created with New.
+type ClientOption func(*AnalyticsClient)
+
+// WithHTTPClient sets a custom HTTP client for sending requests.
+func WithHTTPClient(client HTTPClient) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.HTTPClient = client
+	}
+}
+
+// WithEndpoint sets the Measurement Protocol collection endpoint.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.Endpoint = endpoint
+	}
+}
+
+// WithDebugEndpoint sets the Measurement Protocol validation endpoint used in debug mode.
+func WithDebugEndpoint(endpoint string) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.DebugEndpoint = endpoint
+	}
+}
+
+// WithSanitizer enables lenient sanitizing mode using the provided sanitizer.
+func WithSanitizer(sanitizer *Sanitizer) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.Sanitizer = sanitizer
+	}
+}
+
+// WithSchema sets the event catalog events are validated against and how violations are handled.
+func WithSchema(schema *Schema, mode SchemaMode) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.Schema = schema
+		c.SchemaMode = mode
+	}
+}
+
+// WithSchemaViolationHandler sets the handler called with schema violations in SchemaWarn mode.
+func WithSchemaViolationHandler(handler func(event EventParams, err error)) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.OnSchemaViolation = handler
+	}
+}
+
+// WithBotPolicy sets how events sent with a context classified as bot traffic are handled, see BotMiddleware.
+func WithBotPolicy(policy BotPolicy) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.BotPolicy = policy
+	}
+}
+
+// WithSampler sets the sampler deciding which events are sent.
+func WithSampler(sampler *Sampler) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.Sampler = sampler
+	}
+}
+
+// WithBeforeSend appends hooks to the chain run before every payload is sent.
+func WithBeforeSend(hooks ...BeforeSendHook) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.AddBeforeSend(hooks...)
+	}
+}
+
+// WithAfterSend appends hooks to the chain run after every payload is sent.
+func WithAfterSend(hooks ...AfterSendHook) ClientOption {
+	return func(c *AnalyticsClient) {
+		c.AddAfterSend(hooks...)
+	}
+}
@@ -2,6 +2,7 @@ package ga4m
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -28,3 +29,75 @@ func TestNewClient(t *testing.T) {
 		t.Errorf("Unexpected HTTPClient timeout %s", client.HTTPClient.(*http.Client).Timeout)
 	}
 }
+
+func TestNew(t *testing.T) {
+	mockClient := &MockHTTPClient{}
+
+	client, err := New("G-ABC123XYZ", "test_secret",
+		WithHTTPClient(mockClient),
+		WithEndpoint("https://example.com/mp/collect"),
+		WithDebugEndpoint("https://example.com/debug/mp/collect"),
+		WithSanitizer(NewSanitizer()),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if client.HTTPClient != mockClient {
+		t.Errorf("Expected custom HTTPClient to be set")
+	}
+	if client.Endpoint != "https://example.com/mp/collect" {
+		t.Errorf("Unexpected Endpoint %s", client.Endpoint)
+	}
+	if client.DebugEndpoint != "https://example.com/debug/mp/collect" {
+		t.Errorf("Unexpected DebugEndpoint %s", client.DebugEndpoint)
+	}
+	if client.Sanitizer == nil {
+		t.Errorf("Expected Sanitizer to be set")
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		measurementID string
+		apiSecret     string
+		opts          []ClientOption
+		expected      string
+	}{
+		{"Empty Measurement ID", "", "test_secret", nil, "measurement ID cannot be empty"},
+		{"Universal Analytics ID", "UA-12345-1", "test_secret", nil, "must be in the format"},
+		{"Lowercase Measurement ID", "g-abc123", "test_secret", nil, "must be in the format"},
+		{"Empty API Secret", "G-ABC123", "", nil, "API secret cannot be empty"},
+		{"Relative Endpoint", "G-ABC123", "test_secret", []ClientOption{WithEndpoint("/mp/collect")}, "invalid endpoint"},
+		{"Unparseable Endpoint", "G-ABC123", "test_secret", []ClientOption{WithEndpoint("http://[::1")}, "invalid endpoint"},
+		{"Bad Debug Endpoint", "G-ABC123", "test_secret", []ClientOption{WithDebugEndpoint("ftp://example.com")}, "invalid debug endpoint"},
+		{"Nil HTTP Client", "G-ABC123", "test_secret", []ClientOption{WithHTTPClient(nil)}, "HTTP client cannot be nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.measurementID, tt.apiSecret, tt.opts...)
+			if err == nil {
+				t.Fatalf("Expected error, got nil")
+			}
+			if client != nil {
+				t.Errorf("Expected nil client on error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing '%s', got '%v'", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	client := NewClient("", "")
+
+	err := client.Validate()
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "measurement ID") || !strings.Contains(err.Error(), "API secret") {
+		t.Errorf("Expected both measurement ID and API secret errors, got '%v'", err)
+	}
+}
@@ -0,0 +1,117 @@
+package ga4m
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// collectorContextKey is the context key for the request-scoped Collector.
+type collectorContextKey struct{}
+
+// Collector gathers the events tracked while handling a request so they can be sent together.
+// It is safe for concurrent use.
+type Collector struct {
+	mu     sync.Mutex
+	events []EventParams
+}
+
+// NewCollectorContext returns a copy of ctx carrying a new Collector, and the Collector.
+func NewCollectorContext(ctx context.Context) (context.Context, *Collector) {
+	collector := &Collector{}
+	return context.WithValue(ctx, collectorContextKey{}, collector), collector
+}
+
+// CollectorFromContext returns the Collector stored in ctx by NewCollectorContext or CollectorMiddleware, if any.
+func CollectorFromContext(ctx context.Context) (*Collector, bool) {
+	collector, ok := ctx.Value(collectorContextKey{}).(*Collector)
+	return collector, ok
+}
+
+// Track adds an event to the Collector in ctx, to be sent with the request's other events when the handler
+// returns. The params are copied and the event is timestamped now, so events keep the order they were tracked
+// in. It reports whether ctx carried a Collector; without one the event is discarded.
+func Track(ctx context.Context, name string, params map[string]string) bool {
+	collector, ok := CollectorFromContext(ctx)
+	if !ok {
+		return false
+	}
+	collector.Add(name, params)
+	return true
+}
+
+// Add adds an event to the collector, copying the params and timestamping it now.
+func (c *Collector) Add(name string, params map[string]string) {
+	event := EventParams{Name: name, Params: maps.Clone(params), TimestampMicros: time.Now().UnixMicro()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+// Events returns the events collected so far.
+func (c *Collector) Events() []EventParams {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]EventParams(nil), c.events...)
+}
+
+// CollectorConfig configures the collector middleware.
+type CollectorConfig struct {
+	// Client sends the events. Required unless Sender is set.
+	Client *AnalyticsClient
+
+	// Timeout bounds sending a request's events, defaults to DefaultSendTimeout.
+	Timeout time.Duration
+
+	// MaxInFlight bounds the number of requests whose events are being sent at once, defaults to
+	// DefaultMaxInFlight. Events beyond the limit are dropped rather than blocking the response.
+	MaxInFlight int
+
+	// OnError, if set, is called with errors from sending events, including ErrEventDropped.
+	OnError func(error)
+
+	// Sender, if set, sends the events instead of a sender created from Client, Timeout, MaxInFlight and
+	// OnError. Pass a caller-owned sender to call its Wait during graceful shutdown.
+	Sender *EventSender
+}
+
+// CollectorMiddleware attaches a Collector to the request context so handlers can call Track. When the
+// handler returns, the collected events are sent in the background with the request's session, in batches
+// of at most MaxEventsPerRequest events, instead of one request per event. Use after
+// GoogleAnalyticsCookieMiddleware, or the session is parsed from the request cookies. It panics if neither
+// Sender nor Client is set.
+func CollectorMiddleware(config CollectorConfig) func(http.Handler) http.Handler {
+	sender := config.Sender
+	if sender == nil {
+		sender = NewEventSender(EventSenderConfig{
+			Client:      config.Client,
+			Timeout:     config.Timeout,
+			MaxInFlight: config.MaxInFlight,
+			OnError:     config.OnError,
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, collector := NewCollectorContext(r.Context())
+			r = r.WithContext(ctx)
+			next.ServeHTTP(w, r)
+
+			events := collector.Events()
+			if len(events) == 0 {
+				return
+			}
+			session, ok := SessionFromContext(r.Context())
+			if !ok {
+				session = ParseSessionFromRequest(r)
+			}
+			if session.ClientID == "" {
+				return
+			}
+			sender.SendEvents(r.Context(), session, events)
+		})
+	}
+}
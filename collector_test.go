@@ -0,0 +1,91 @@
+package ga4m
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrack_WithoutCollector(t *testing.T) {
+	assert.False(t, Track(context.Background(), "view_item", nil))
+}
+
+func TestCollector(t *testing.T) {
+	ctx, collector := NewCollectorContext(context.Background())
+	params := map[string]string{"item_id": "SKU_1"}
+
+	assert.True(t, Track(ctx, "view_item", params))
+	assert.True(t, Track(ctx, "add_to_cart", params))
+	params["item_id"] = "SKU_2" // the collected params are copies
+
+	events := collector.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "view_item", events[0].Name)
+	assert.Equal(t, "add_to_cart", events[1].Name)
+	assert.Equal(t, "SKU_1", events[0].Params["item_id"])
+	assert.NotZero(t, events[0].TimestampMicros)
+	assert.LessOrEqual(t, events[0].TimestampMicros, events[1].TimestampMicros)
+}
+
+func TestCollectorMiddleware(t *testing.T) {
+	client, payloads := recordingClient(t)
+	handler := GoogleAnalyticsCookieMiddleware()(CollectorMiddleware(CollectorConfig{Client: client})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Track(r.Context(), "view_item", map[string]string{"item_id": "SKU_1"})
+			Track(r.Context(), "add_to_cart", map[string]string{"item_id": "SKU_1"})
+			Track(r.Context(), "custom_event", nil)
+		})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "ABC123", Value: "GS1.1.1731019235.2.1.1731019762.0.0.0"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// all events are sent in a single request carrying the session
+	assert.Eventually(t, func() bool { return len(payloads()) == 1 }, time.Second, 5*time.Millisecond)
+	payload := payloads()[0]
+	assert.Equal(t, "71807069.1731019235", payload.ClientID)
+	require.Len(t, payload.Events, 3)
+	assert.Equal(t, "view_item", payload.Events[0].Name)
+	assert.Equal(t, "custom_event", payload.Events[2].Name)
+	assert.Equal(t, "1731019235", payload.Events[0].Params["session_id"])
+}
+
+func TestCollectorMiddleware_Chunks(t *testing.T) {
+	client, payloads := recordingClient(t)
+	sender := NewEventSender(EventSenderConfig{Client: client})
+	handler := CollectorMiddleware(CollectorConfig{Sender: sender})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := range MaxEventsPerRequest + 5 {
+			Track(r.Context(), fmt.Sprintf("event_%d", i), nil)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	sender.Wait()
+	sent := payloads()
+	require.Len(t, sent, 2)
+	assert.Len(t, sent[0].Events, MaxEventsPerRequest)
+	assert.Len(t, sent[1].Events, 5)
+	assert.Equal(t, "event_25", sent[1].Events[0].Name)
+}
+
+func TestCollectorMiddleware_NoEvents(t *testing.T) {
+	client, payloads := recordingClient(t)
+	handler := CollectorMiddleware(CollectorConfig{Client: client})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, payloads())
+}
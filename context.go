@@ -0,0 +1,41 @@
+package ga4m
+
+import "context"
+
+// sessionContextKey is the context key for the Google Analytics session.
+type sessionContextKey struct{}
+
+// NewContext returns a copy of ctx carrying the Google Analytics session.
+func NewContext(ctx context.Context, session Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// SessionFromContext returns the Google Analytics session stored in ctx by NewContext or the middleware, if any.
+func SessionFromContext(ctx context.Context) (Session, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(Session)
+	return session, ok
+}
+
+// fpidContextKey is the context key for the client ID resolved by FPIDMiddleware.
+type fpidContextKey struct{}
+
+// fpidClientIDFromContext returns the client ID stored in ctx by FPIDMiddleware, if any.
+func fpidClientIDFromContext(ctx context.Context) (string, bool) {
+	clientID, ok := ctx.Value(fpidContextKey{}).(string)
+	return clientID, ok && clientID != ""
+}
+
+// userIDContextKey is the context key for the user ID.
+type userIDContextKey struct{}
+
+// NewUserIDContext returns a copy of ctx carrying the user ID, for use with WithUserID when sending events
+// and for propagation to downstream services by Transport.
+func NewUserIDContext(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserIDFromContext returns the user ID stored in ctx by NewUserIDContext or IdentityMiddleware, if any.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDContextKey{}).(string)
+	return userID, ok && userID != ""
+}
@@ -0,0 +1,50 @@
+package ga4m
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionContext(t *testing.T) {
+	_, ok := SessionFromContext(context.Background())
+	assert.False(t, ok)
+
+	session := Session{ClientID: "476555468.1726969270", SessionID: "1731019235"}
+	ctx := NewContext(context.Background(), session)
+
+	got, ok := SessionFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, session, got)
+
+	// the string key used by framework middleware does not collide
+	ctx = context.WithValue(context.Background(), ContextKey, session) //nolint:staticcheck
+	_, ok = SessionFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func TestSessionContext_Goroutine(t *testing.T) {
+	session := Session{ClientID: "476555468.1726969270"}
+	ctx := NewContext(context.Background(), session)
+
+	done := make(chan Session)
+	go func(ctx context.Context) {
+		got, _ := SessionFromContext(ctx)
+		done <- got
+	}(context.WithoutCancel(ctx))
+
+	assert.Equal(t, session, <-done)
+}
+
+func TestUserIDContext(t *testing.T) {
+	_, ok := UserIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	userID, ok := UserIDFromContext(NewUserIDContext(context.Background(), "user_42"))
+	assert.True(t, ok)
+	assert.Equal(t, "user_42", userID)
+
+	_, ok = UserIDFromContext(NewUserIDContext(context.Background(), ""))
+	assert.False(t, ok)
+}
@@ -0,0 +1,107 @@
+package ga4m
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultCookieMaxAge is the lifetime gtag.js gives the _ga and _ga_* cookies.
+const DefaultCookieMaxAge = 2 * 365 * 24 * time.Hour
+
+// CookieOptions configures the Google Analytics cookies written by the server.
+type CookieOptions struct {
+	Domain   string        // Cookie domain (e.g., ".example.com"), empty for a host-only cookie
+	Path     string        // Cookie path, defaults to "/"
+	MaxAge   time.Duration // Cookie lifetime, defaults to DefaultCookieMaxAge
+	SameSite http.SameSite // SameSite attribute, defaults to http.SameSiteLaxMode
+	Secure   bool          // Whether the cookie is only sent over HTTPS
+
+	Format SessionCookieFormat // Session cookie format, defaults to SessionCookieGS1
+}
+
+// NewClientID generates a GA-compatible client ID, a random 31-bit number followed by the Unix timestamp (e.g., "476555468.1726969270").
+func NewClientID(now time.Time) string {
+	return fmt.Sprintf("%d.%d", rand.Int32N(math.MaxInt32)+1, now.Unix())
+}
+
+// NewSession returns the Session of a new visitor whose first visit and first session start at now.
+func NewSession(now time.Time) Session {
+	now = now.Truncate(time.Second)
+	return Session{
+		ClientID:       NewClientID(now),
+		ClientVersion:  "1",
+		FirstVisit:     now,
+		SessionCount:   1,
+		LastSession:    now,
+		SessionID:      strconv.FormatInt(now.Unix(), 10),
+		SessionVersion: "1",
+		IsFirstSession: true,
+		IsNewSession:   true,
+	}
+}
+
+// EnsureSession parses the session for the measurement ID's stream from the request. If the request has
+// no GA cookies, a new client ID and session are minted and the matching _ga and _ga_* cookies are written
+// to w, so gtag.js on the page adopts the same identity. If only the session cookie is missing, a new
+// session is started for the existing client ID and only the _ga_* cookie is written. The measurement ID
+// is required, as it names the session cookie.
+func EnsureSession(w http.ResponseWriter, r *http.Request, measurementID string, opts CookieOptions) Session {
+	session := ParseStreamSession(r, measurementID)
+	if session.ClientID != "" && session.SessionID != "" {
+		return session
+	}
+
+	minted := NewSession(time.Now())
+	if session.ClientID == "" {
+		session.ClientID = minted.ClientID
+		session.ClientVersion = minted.ClientVersion
+		session.FirstVisit = minted.FirstVisit
+		http.SetCookie(w, opts.cookie(clientCookieName, session.ClientCookieValue()))
+	}
+
+	session.SessionCount = max(session.SessionCount, 1)
+	session.LastSession = minted.LastSession
+	session.SessionID = minted.SessionID
+	session.SessionVersion = minted.SessionVersion
+	session.IsFirstSession = session.SessionCount == 1
+	session.IsNewSession = true
+	http.SetCookie(w, opts.cookie(sessionCookieName+ContainerID(measurementID), session.SessionCookieValue(opts.Format)))
+
+	return session
+}
+
+// SetSessionCookies writes the _ga and _ga_* cookies for the session and the measurement ID's stream.
+func SetSessionCookies(w http.ResponseWriter, measurementID string, session Session, opts CookieOptions) {
+	http.SetCookie(w, opts.cookie(clientCookieName, session.ClientCookieValue()))
+	http.SetCookie(w, opts.cookie(sessionCookieName+ContainerID(measurementID), session.SessionCookieValue(opts.Format)))
+}
+
+// cookie builds a cookie with the options applied. GA cookies are read by gtag.js, so they are never HttpOnly.
+func (o CookieOptions) cookie(name, value string) *http.Cookie {
+	path := o.Path
+	if path == "" {
+		path = "/"
+	}
+	maxAge := o.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultCookieMaxAge
+	}
+	sameSite := o.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   o.Domain,
+		Path:     path,
+		MaxAge:   int(maxAge / time.Second),
+		Expires:  time.Now().Add(maxAge),
+		SameSite: sameSite,
+		Secure:   o.Secure,
+	}
+}
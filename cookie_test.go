@@ -0,0 +1,129 @@
+package ga4m
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientID(t *testing.T) {
+	now := time.Unix(1731019235, 0)
+	id := NewClientID(now)
+
+	assert.Regexp(t, regexp.MustCompile(`^[1-9][0-9]*\.1731019235$`), id)
+	assert.NotEqual(t, id, NewClientID(now))
+}
+
+func TestNewSession(t *testing.T) {
+	now := time.Unix(1731019235, 500)
+	session := NewSession(now)
+
+	assert.NotEmpty(t, session.ClientID)
+	assert.Equal(t, "1731019235", session.SessionID)
+	assert.Equal(t, 1, session.SessionCount)
+	assert.Equal(t, time.Unix(1731019235, 0), session.FirstVisit)
+	assert.True(t, session.IsFirstSession)
+	assert.True(t, session.IsNewSession)
+}
+
+func TestEnsureSession_MintsCookies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	session := EnsureSession(rec, req, "G-ABC123", CookieOptions{
+		Domain:   ".example.com",
+		SameSite: http.SameSiteStrictMode,
+		Secure:   true,
+	})
+	require.NotEmpty(t, session.ClientID)
+	require.NotEmpty(t, session.SessionID)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 2)
+	for _, cookie := range cookies {
+		assert.Equal(t, "example.com", cookie.Domain)
+		assert.Equal(t, "/", cookie.Path)
+		assert.Equal(t, int(DefaultCookieMaxAge/time.Second), cookie.MaxAge)
+		assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		assert.True(t, cookie.Secure)
+		assert.False(t, cookie.HttpOnly)
+	}
+
+	// the written cookies parse back to the same identity
+	next := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		next.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	parsed := ParseStreamSession(next, "G-ABC123")
+	assert.Equal(t, session.ClientID, parsed.ClientID)
+	assert.Equal(t, session.SessionID, parsed.SessionID)
+	assert.Equal(t, 1, parsed.SessionCount)
+	assert.True(t, parsed.IsFirstSession)
+}
+
+func TestEnsureSession_ExistingCookies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "ABC123", Value: "GS1.1.1731019235.2.1.1731019762.0.0.0"})
+	rec := httptest.NewRecorder()
+
+	session := EnsureSession(rec, req, "G-ABC123", CookieOptions{})
+	assert.Equal(t, "71807069.1731019235", session.ClientID)
+	assert.Equal(t, "1731019235", session.SessionID)
+	assert.Empty(t, rec.Result().Cookies())
+}
+
+func TestEnsureSession_MissingSessionCookie(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	rec := httptest.NewRecorder()
+
+	session := EnsureSession(rec, req, "G-ABC123", CookieOptions{})
+	assert.Equal(t, "71807069.1731019235", session.ClientID)
+	assert.NotEmpty(t, session.SessionID)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, sessionCookieName+"ABC123", cookies[0].Name)
+}
+
+func TestSetSessionCookies(t *testing.T) {
+	session := Session{
+		ClientID:       "476555468.1726969270",
+		ClientVersion:  "1",
+		SessionID:      "1731019235",
+		SessionCount:   3,
+		IsEngaged:      true,
+		LastSession:    time.Unix(1731019762, 0),
+		HitCount:       4,
+		SessionVersion: "1",
+	}
+	rec := httptest.NewRecorder()
+
+	SetSessionCookies(rec, "G-ABC123", session, CookieOptions{Path: "/app", MaxAge: time.Hour})
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 2)
+	assert.Equal(t, clientCookieName, cookies[0].Name)
+	assert.Equal(t, "GA1.1.476555468.1726969270", cookies[0].Value)
+	assert.Equal(t, sessionCookieName+"ABC123", cookies[1].Name)
+	assert.Equal(t, "GS1.1.1731019235.3.1.1731019762.4.0.0", cookies[1].Value)
+	assert.Equal(t, "/app", cookies[1].Path)
+	assert.Equal(t, 3600, cookies[1].MaxAge)
+}
+
+func TestSetSessionCookies_GS2(t *testing.T) {
+	session := Session{ClientID: "476555468.1726969270", SessionID: "1731019235", SessionCount: 1}
+	rec := httptest.NewRecorder()
+
+	SetSessionCookies(rec, "G-ABC123", session, CookieOptions{Format: SessionCookieGS2})
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 2)
+	assert.Equal(t, "GS2.1.s1731019235$o1$g0$t0$j0$l0$h0", cookies[1].Value)
+}
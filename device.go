@@ -0,0 +1,419 @@
+package ga4m
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DeviceCategoryDesktop is the device category of desktop and laptop computers
+	DeviceCategoryDesktop = "desktop"
+
+	// DeviceCategoryMobile is the device category of phones
+	DeviceCategoryMobile = "mobile"
+
+	// DeviceCategoryTablet is the device category of tablets
+	DeviceCategoryTablet = "tablet"
+)
+
+// Device is the device information sent with a Measurement Protocol payload.
+type Device struct {
+	Category               string `json:"category,omitempty"`                 // desktop, mobile or tablet
+	Language               string `json:"language,omitempty"`                 // e.g., "en-us"
+	ScreenResolution       string `json:"screen_resolution,omitempty"`        // e.g., "1280x2856"
+	OperatingSystem        string `json:"operating_system,omitempty"`         // e.g., "Android"
+	OperatingSystemVersion string `json:"operating_system_version,omitempty"` // e.g., "14"
+	Model                  string `json:"model,omitempty"`                    // e.g., "Pixel 8"
+	Brand                  string `json:"brand,omitempty"`                    // e.g., "Google"
+	Browser                string `json:"browser,omitempty"`                  // e.g., "Chrome"
+	BrowserVersion         string `json:"browser_version,omitempty"`          // e.g., "124.0.6367.91"
+}
+
+// browserRule identifies a browser by a User-Agent token followed by its version, e.g. "Firefox/125.0".
+type browserRule struct {
+	name  string
+	token string
+}
+
+// browserRules are checked in order, so browsers that also claim to be Chrome or Safari come first.
+var browserRules = []browserRule{
+	{"Edge", "Edg/"},
+	{"Edge", "EdgA/"},
+	{"Edge", "EdgiOS/"},
+	{"Edge", "Edge/"},
+	{"Opera", "OPR/"},
+	{"Opera", "OPiOS/"},
+	{"Samsung Internet", "SamsungBrowser/"},
+	{"Yandex", "YaBrowser/"},
+	{"UC Browser", "UCBrowser/"},
+	{"Firefox", "FxiOS/"},
+	{"Firefox", "Firefox/"},
+	{"Chrome", "CriOS/"},
+	{"Chrome", "Chrome/"},
+	{"Safari", "Version/"},
+	{"Internet Explorer", "MSIE "},
+	{"Internet Explorer", "rv:"},
+}
+
+// windowsVersions maps Windows NT kernel versions to marketing versions.
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+// modelBrands maps Android model prefixes to brands.
+var modelBrands = []struct {
+	prefix string
+	brand  string
+}{
+	{"SM-", "Samsung"},
+	{"GT-", "Samsung"},
+	{"Pixel", "Google"},
+	{"Nexus", "Google"},
+	{"Redmi", "Xiaomi"},
+	{"Mi ", "Xiaomi"},
+	{"M2", "Xiaomi"},
+	{"ONEPLUS", "OnePlus"},
+	{"CPH", "OPPO"},
+	{"moto", "Motorola"},
+	{"Moto", "Motorola"},
+	{"LM-", "LG"},
+	{"Nokia", "Nokia"},
+	{"HUAWEI", "Huawei"},
+	{"VOG-", "Huawei"},
+	{"vivo", "vivo"},
+	{"V2", "vivo"},
+	{"RMX", "realme"},
+}
+
+// ParseDevice derives the device information from a request's User-Agent and Sec-CH-UA* client hint headers.
+// Client hints are preferred where present, as browsers freeze the equivalent User-Agent values.
+func ParseDevice(r *http.Request) Device {
+	device := ParseUserAgent(r.UserAgent())
+	device.Language = preferredLanguage(r.Header.Get("Accept-Language"))
+
+	switch r.Header.Get("Sec-CH-UA-Mobile") {
+	case "?1":
+		device.Category = DeviceCategoryMobile
+	case "?0":
+		if device.Category == DeviceCategoryMobile {
+			device.Category = DeviceCategoryTablet
+		}
+	}
+	if platform := unquoteHint(r.Header.Get("Sec-CH-UA-Platform")); platform != "" {
+		device.OperatingSystem = hintPlatformName(platform)
+		device.OperatingSystemVersion = ""
+	}
+	if version := unquoteHint(r.Header.Get("Sec-CH-UA-Platform-Version")); version != "" {
+		device.OperatingSystemVersion = version
+		if device.OperatingSystem == "Windows" {
+			device.OperatingSystemVersion = windowsHintVersion(version)
+		}
+	}
+	if model := unquoteHint(r.Header.Get("Sec-CH-UA-Model")); model != "" {
+		device.Model = model
+		device.Brand = brandForModel(model)
+	}
+	if brand, version := hintBrowser(r.Header.Get("Sec-CH-UA-Full-Version-List")); brand != "" {
+		device.Browser, device.BrowserVersion = brand, version
+	} else if brand, version := hintBrowser(r.Header.Get("Sec-CH-UA")); brand != "" && brand != device.Browser {
+		// the low entropy hint only carries the major version, so keep the User-Agent's when the brand agrees
+		device.Browser, device.BrowserVersion = brand, version
+	}
+
+	return device
+}
+
+// ParseUserAgent derives the device category, operating system, browser and model from a User-Agent string.
+func ParseUserAgent(ua string) Device {
+	var device Device
+	if ua == "" {
+		return device
+	}
+
+	// operating system and model
+	switch {
+	case strings.Contains(ua, "iPad"):
+		device.OperatingSystem = "iOS"
+		device.OperatingSystemVersion = underscoreVersion(ua, "CPU OS ")
+		device.Model, device.Brand = "iPad", "Apple"
+		device.Category = DeviceCategoryTablet
+	case strings.Contains(ua, "iPhone"):
+		device.OperatingSystem = "iOS"
+		device.OperatingSystemVersion = underscoreVersion(ua, "iPhone OS ")
+		device.Model, device.Brand = "iPhone", "Apple"
+		device.Category = DeviceCategoryMobile
+	case strings.Contains(ua, "Android"):
+		device.OperatingSystem = "Android"
+		device.OperatingSystemVersion = versionAfter(ua, "Android ")
+		device.Model = androidModel(ua)
+		device.Brand = brandForModel(device.Model)
+		device.Category = DeviceCategoryTablet
+		if strings.Contains(ua, "Mobile") {
+			device.Category = DeviceCategoryMobile
+		}
+	case strings.Contains(ua, "Windows"):
+		device.OperatingSystem = "Windows"
+		device.OperatingSystemVersion = windowsVersions[versionAfter(ua, "Windows NT ")]
+		device.Category = DeviceCategoryDesktop
+	case strings.Contains(ua, "Macintosh"):
+		device.OperatingSystem = "Macintosh"
+		device.OperatingSystemVersion = underscoreVersion(ua, "Mac OS X ")
+		device.Brand = "Apple"
+		device.Category = DeviceCategoryDesktop
+	case strings.Contains(ua, "CrOS"):
+		device.OperatingSystem = "Chrome OS"
+		device.Category = DeviceCategoryDesktop
+	case strings.Contains(ua, "Linux"):
+		device.OperatingSystem = "Linux"
+		device.Category = DeviceCategoryDesktop
+	}
+	if device.Category == "" {
+		device.Category = DeviceCategoryDesktop
+		if strings.Contains(ua, "Mobi") {
+			device.Category = DeviceCategoryMobile
+		}
+	}
+
+	// browser
+	for _, rule := range browserRules {
+		if version := versionAfter(ua, rule.token); version != "" {
+			if rule.token == "Version/" && !strings.Contains(ua, "Safari/") {
+				continue
+			}
+			if rule.token == "rv:" && !strings.Contains(ua, "Trident/") {
+				continue
+			}
+			device.Browser, device.BrowserVersion = rule.name, version
+			break
+		}
+	}
+
+	return device
+}
+
+// versionAfter returns the version number that immediately follows token in s, or an empty string.
+func versionAfter(s, token string) string {
+	i := strings.Index(s, token)
+	if i < 0 {
+		return ""
+	}
+	rest := s[i+len(token):]
+	end := 0
+	for end < len(rest) && (rest[end] == '.' || (rest[end] >= '0' && rest[end] <= '9')) {
+		end++
+	}
+	return strings.TrimRight(rest[:end], ".")
+}
+
+// underscoreVersion returns an underscore-separated version following token as a dotted version (e.g., "17_4" -> "17.4").
+func underscoreVersion(s, token string) string {
+	i := strings.Index(s, token)
+	if i < 0 {
+		return ""
+	}
+	rest := s[i+len(token):]
+	end := 0
+	for end < len(rest) && (rest[end] == '_' || rest[end] == '.' || (rest[end] >= '0' && rest[end] <= '9')) {
+		end++
+	}
+	return strings.ReplaceAll(strings.Trim(rest[:end], "_."), "_", ".")
+}
+
+// androidModel returns the model from an Android User-Agent, e.g. "Pixel 8" from "(Linux; Android 14; Pixel 8 Build/UQ1A)".
+func androidModel(ua string) string {
+	i := strings.Index(ua, "Android")
+	if i < 0 {
+		return ""
+	}
+	end := strings.IndexByte(ua[i:], ')')
+	if end < 0 {
+		return ""
+	}
+	segments := strings.Split(ua[i:i+end], ";")
+	if len(segments) < 2 {
+		return ""
+	}
+	model := strings.TrimSpace(segments[len(segments)-1])
+	if b := strings.Index(model, " Build/"); b >= 0 {
+		model = model[:b]
+	}
+	// reduced User-Agents replace the model with "K"
+	if model == "K" || strings.HasPrefix(model, "Android") || strings.HasPrefix(model, "wv") {
+		return ""
+	}
+	return model
+}
+
+// brandForModel returns the brand of an Android model, or an empty string if unknown.
+func brandForModel(model string) string {
+	for _, mb := range modelBrands {
+		if strings.HasPrefix(model, mb.prefix) {
+			return mb.brand
+		}
+	}
+	return ""
+}
+
+// unquoteHint removes the quotes around a structured header string value.
+func unquoteHint(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"`)
+}
+
+// hintPlatformName maps a Sec-CH-UA-Platform value to the operating system name GA reports.
+func hintPlatformName(platform string) string {
+	switch platform {
+	case "macOS":
+		return "Macintosh"
+	case "Chrome OS", "Chromium OS":
+		return "Chrome OS"
+	default:
+		return platform
+	}
+}
+
+// windowsHintVersion maps a Sec-CH-UA-Platform-Version value on Windows to its marketing version.
+// Versions 13 and above are Windows 11, 1 through 10 are Windows 10.
+func windowsHintVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	switch {
+	case err != nil || n < 1:
+		return ""
+	case n >= 13:
+		return "11"
+	default:
+		return "10"
+	}
+}
+
+// hintBrowser returns the most specific brand and version from a Sec-CH-UA or Sec-CH-UA-Full-Version-List
+// header, skipping GREASE brands and preferring a named browser over the Chromium engine.
+func hintBrowser(header string) (brand, version string) {
+	for _, hint := range parseBrandList(header) {
+		if hint.brand == "" || strings.Contains(hint.brand, "Brand") {
+			continue
+		}
+		if hint.brand == "Chromium" {
+			if brand == "" {
+				brand, version = "Chrome", hint.version
+			}
+			continue
+		}
+		return hintBrowserName(hint.brand), hint.version
+	}
+	return brand, version
+}
+
+// brandHint is a brand and its version from a Sec-CH-UA brand list.
+type brandHint struct {
+	brand   string
+	version string
+}
+
+// parseBrandList parses a Sec-CH-UA brand list, a structured field list of quoted brands with a v parameter
+// such as `"Not)A;Brand";v="99", "Google Chrome";v="127"`. Brands are parsed as quoted strings first, so
+// GREASE brands containing separators such as ';', ',' and '=' stay intact. Parsing stops at malformed input.
+func parseBrandList(header string) []brandHint {
+	var hints []brandHint
+	p := hintParser{s: header}
+	for {
+		p.skipSpace()
+		if p.done() {
+			return hints
+		}
+
+		var hint brandHint
+		hint.brand = p.item()
+		for p.consume(';') {
+			p.skipSpace()
+			key := p.token()
+			value := ""
+			if p.consume('=') {
+				value = p.item()
+			}
+			if key == "v" {
+				hint.version = value
+			}
+		}
+		hints = append(hints, hint)
+
+		p.skipSpace()
+		if !p.consume(',') {
+			return hints
+		}
+	}
+}
+
+// hintParser is a cursor over a structured header value.
+type hintParser struct {
+	s string
+	i int
+}
+
+func (p *hintParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *hintParser) skipSpace() {
+	for !p.done() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// consume advances past c if it is the next byte.
+func (p *hintParser) consume(c byte) bool {
+	if !p.done() && p.s[p.i] == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// item reads a quoted string, unescaping it, or a bare token.
+func (p *hintParser) item() string {
+	if !p.consume('"') {
+		return p.token()
+	}
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '"':
+			return b.String()
+		case c == '\\' && !p.done():
+			b.WriteByte(p.s[p.i])
+			p.i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// token reads a bare token up to the next separator.
+func (p *hintParser) token() string {
+	start := p.i
+	for !p.done() && !strings.ContainsRune(";,= \t\"", rune(p.s[p.i])) {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// hintBrowserName maps a client hint brand to the browser name GA reports.
+func hintBrowserName(brand string) string {
+	switch brand {
+	case "Google Chrome":
+		return "Chrome"
+	case "Microsoft Edge":
+		return "Edge"
+	default:
+		return brand
+	}
+}
@@ -0,0 +1,224 @@
+package ga4m
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chromeWindowsUA  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+	safariIPhoneUA   = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Mobile/15E148 Safari/604.1"
+	chromeAndroidUA  = "Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/UQ1A.240205.004) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Mobile Safari/537.36"
+	reducedAndroidUA = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		ua       string
+		expected Device
+	}{
+		{
+			name: "Chrome On Windows",
+			ua:   chromeWindowsUA,
+			expected: Device{
+				Category: DeviceCategoryDesktop, OperatingSystem: "Windows", OperatingSystemVersion: "10",
+				Browser: "Chrome", BrowserVersion: "124.0.0.0",
+			},
+		},
+		{
+			name: "Edge On Windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.67",
+			expected: Device{
+				Category: DeviceCategoryDesktop, OperatingSystem: "Windows", OperatingSystemVersion: "10",
+				Browser: "Edge", BrowserVersion: "124.0.2478.67",
+			},
+		},
+		{
+			name: "Safari On Mac",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			expected: Device{
+				Category: DeviceCategoryDesktop, OperatingSystem: "Macintosh", OperatingSystemVersion: "10.15.7",
+				Brand: "Apple", Browser: "Safari", BrowserVersion: "17.4",
+			},
+		},
+		{
+			name: "Firefox On Linux",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			expected: Device{
+				Category: DeviceCategoryDesktop, OperatingSystem: "Linux",
+				Browser: "Firefox", BrowserVersion: "125.0",
+			},
+		},
+		{
+			name: "Safari On iPhone",
+			ua:   safariIPhoneUA,
+			expected: Device{
+				Category: DeviceCategoryMobile, OperatingSystem: "iOS", OperatingSystemVersion: "17.4.1",
+				Model: "iPhone", Brand: "Apple", Browser: "Safari", BrowserVersion: "17.4.1",
+			},
+		},
+		{
+			name: "Chrome On iPad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+			expected: Device{
+				Category: DeviceCategoryTablet, OperatingSystem: "iOS", OperatingSystemVersion: "16.6",
+				Model: "iPad", Brand: "Apple", Browser: "Chrome", BrowserVersion: "124.0.6367.88",
+			},
+		},
+		{
+			name: "Chrome On Pixel",
+			ua:   chromeAndroidUA,
+			expected: Device{
+				Category: DeviceCategoryMobile, OperatingSystem: "Android", OperatingSystemVersion: "14",
+				Model: "Pixel 8", Brand: "Google", Browser: "Chrome", BrowserVersion: "124.0.6367.82",
+			},
+		},
+		{
+			name: "Samsung Tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Safari/537.36",
+			expected: Device{
+				Category: DeviceCategoryTablet, OperatingSystem: "Android", OperatingSystemVersion: "13",
+				Model: "SM-X710", Brand: "Samsung", Browser: "Samsung Internet", BrowserVersion: "24.0",
+			},
+		},
+		{
+			name: "Reduced Android",
+			ua:   reducedAndroidUA,
+			expected: Device{
+				Category: DeviceCategoryMobile, OperatingSystem: "Android", OperatingSystemVersion: "10",
+				Browser: "Chrome", BrowserVersion: "124.0.0.0",
+			},
+		},
+		{
+			name: "Internet Explorer 11",
+			ua:   "Mozilla/5.0 (Windows NT 6.1; Trident/7.0; rv:11.0) like Gecko",
+			expected: Device{
+				Category: DeviceCategoryDesktop, OperatingSystem: "Windows", OperatingSystemVersion: "7",
+				Browser: "Internet Explorer", BrowserVersion: "11.0",
+			},
+		},
+		{
+			name:     "Empty",
+			ua:       "",
+			expected: Device{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseUserAgent(tt.ua))
+		})
+	}
+}
+
+func TestParseDevice_ClientHints(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", reducedAndroidUA)
+	req.Header.Set("Accept-Language", "en-GB,en;q=0.8")
+	req.Header.Set("Sec-CH-UA", `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`)
+	req.Header.Set("Sec-CH-UA-Full-Version-List", `"Chromium";v="124.0.6367.91", "Google Chrome";v="124.0.6367.91", "Not-A.Brand";v="99.0.0.0"`)
+	req.Header.Set("Sec-CH-UA-Mobile", "?1")
+	req.Header.Set("Sec-CH-UA-Platform", `"Android"`)
+	req.Header.Set("Sec-CH-UA-Platform-Version", `"14.0.0"`)
+	req.Header.Set("Sec-CH-UA-Model", `"Pixel 8 Pro"`)
+
+	assert.Equal(t, Device{
+		Category:               DeviceCategoryMobile,
+		Language:               "en-gb",
+		OperatingSystem:        "Android",
+		OperatingSystemVersion: "14.0.0",
+		Model:                  "Pixel 8 Pro",
+		Brand:                  "Google",
+		Browser:                "Chrome",
+		BrowserVersion:         "124.0.6367.91",
+	}, ParseDevice(req))
+}
+
+func TestParseDevice_WindowsClientHints(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", chromeWindowsUA)
+	req.Header.Set("Sec-CH-UA", `"Microsoft Edge";v="124", "Chromium";v="124", "Not-A.Brand";v="99"`)
+	req.Header.Set("Sec-CH-UA-Mobile", "?0")
+	req.Header.Set("Sec-CH-UA-Platform", `"Windows"`)
+	req.Header.Set("Sec-CH-UA-Platform-Version", `"15.0.0"`)
+
+	device := ParseDevice(req)
+	assert.Equal(t, DeviceCategoryDesktop, device.Category)
+	assert.Equal(t, "Windows", device.OperatingSystem)
+	assert.Equal(t, "11", device.OperatingSystemVersion)
+	assert.Equal(t, "Edge", device.Browser)
+	assert.Equal(t, "124", device.BrowserVersion)
+}
+
+func TestHintBrowser_Grease(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		browser string
+		version string
+	}{
+		{"Chrome 127", `"Not)A;Brand";v="99", "Google Chrome";v="127", "Chromium";v="127"`, "Chrome", "127"},
+		{"Chrome 128 Full Version", `"Chromium";v="128.0.6613.120", "Not;A=Brand";v="24.0.0.0", "Google Chrome";v="128.0.6613.120"`, "Chrome", "128.0.6613.120"},
+		{"Edge 126", `"Not/A)Brand";v="8", "Chromium";v="126", "Microsoft Edge";v="126"`, "Edge", "126"},
+		{"Chromium Only", `"Not A(Brand";v="99", "Chromium";v="121"`, "Chrome", "121"},
+		{"Escaped And Comma", `"Not\"A,Brand";v="8", "Opera";v="112"`, "Opera", "112"},
+		{"Only Grease", `"Not=A?Brand";v="99"`, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			browser, version := hintBrowser(tt.header)
+			assert.Equal(t, tt.browser, browser)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestSendEvent_WithDevice(t *testing.T) {
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+
+	_, payloadBytes, err := client.BuildPayload(Session{ClientID: "123456.7654321"},
+		[]EventParams{{Name: "page_view"}},
+		WithDevice(ParseUserAgent(safariIPhoneUA)),
+	)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(payloadBytes, &decoded))
+	device := decoded["device"].(map[string]any)
+	assert.Equal(t, "mobile", device["category"])
+	assert.Equal(t, "iOS", device["operating_system"])
+	assert.NotContains(t, device, "screen_resolution")
+
+	// the device is omitted when not set
+	_, payloadBytes, err = client.BuildPayload(Session{ClientID: "123456.7654321"}, []EventParams{{Name: "page_view"}})
+	require.NoError(t, err)
+	assert.NotContains(t, string(payloadBytes), "device")
+}
+
+func BenchmarkParseUserAgent(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseUserAgent(chromeAndroidUA)
+	}
+}
+
+func BenchmarkParseDevice(b *testing.B) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", reducedAndroidUA)
+	req.Header.Set("Sec-CH-UA-Full-Version-List", `"Chromium";v="124.0.6367.91", "Google Chrome";v="124.0.6367.91", "Not-A.Brand";v="99.0.0.0"`)
+	req.Header.Set("Sec-CH-UA-Mobile", "?1")
+	req.Header.Set("Sec-CH-UA-Platform", `"Android"`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParseDevice(req)
+	}
+}
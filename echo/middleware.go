@@ -0,0 +1,78 @@
+// Package ga4mecho provides Echo middleware for the ga4m Google Analytics 4 client.
+package ga4mecho
+
+import (
+	"time"
+
+	"github.com/agentstation/ga4m"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Middleware extracts user Google Analytics session data from cookies and
+// stores it in the echo context for later use, see GetSession.
+// The session is also stored in the request context, see ga4m.SessionFromContext
+func Middleware() echo.MiddlewareFunc {
+	return MiddlewareWithConfig(ga4m.CookieMiddlewareConfig{})
+}
+
+// MiddlewareWithConfig returns a Middleware with config
+func MiddlewareWithConfig(config ga4m.CookieMiddlewareConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			session := config.ParseSession(c.Request())
+			c.Set(ga4m.ContextKey, session)
+			c.SetRequest(c.Request().WithContext(ga4m.NewContext(c.Request().Context(), session)))
+			return next(c)
+		}
+	}
+}
+
+// GetSession returns the Google Analytics session stored in the echo context by Middleware.
+func GetSession(c echo.Context) (ga4m.Session, bool) {
+	session, ok := c.Get(ga4m.ContextKey).(ga4m.Session)
+	return session, ok
+}
+
+// ParseSession returns the Google Analytics session stored in the echo context by Middleware,
+// or parses it from the request cookies if the middleware is not in use.
+func ParseSession(c echo.Context) ga4m.Session {
+	if session, ok := GetSession(c); ok {
+		return session
+	}
+	return ga4m.ParseSessionFromRequest(c.Request())
+}
+
+// PageViewConfig configures the Echo page_view middleware.
+type PageViewConfig struct {
+	// Skipper defines a function to skip the middleware, in addition to PageViewConfig.Matchers.
+	Skipper middleware.Skipper
+
+	ga4m.PageViewConfig
+}
+
+// PageViewMiddleware sends a page_view (or PageViewConfig.EventName) event after each matching response.
+// The route template, such as /users/:id, is used as the page path, and the status code and latency are added
+// as parameters. Events are sent in the background so responses are never delayed; set PageViewConfig.Sender
+// to wait for them on shutdown. Use after Middleware, or the session is parsed from the request cookies.
+func PageViewMiddleware(config PageViewConfig) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+	tracker := ga4m.NewPageViewTracker(config.PageViewConfig)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) || !tracker.Match(c.Request()) {
+				return next(c)
+			}
+
+			start := time.Now()
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			tracker.Track(c.Request(), c.Path(), c.Response().Status, time.Since(start))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package ga4m
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ExceptionEvent is the name of the event GA records for an exception
+	ExceptionEvent = "exception"
+
+	// DefaultExceptionInterval is the default minimum interval between exception events with the same fingerprint
+	DefaultExceptionInterval = time.Minute
+
+	// maxStackDepth is the number of stack frames used for a panic fingerprint
+	maxStackDepth = 32
+)
+
+// ExceptionConfig configures the exception middleware.
+type ExceptionConfig struct {
+	// Client sends the events. Required unless Sender is set.
+	Client *AnalyticsClient
+
+	// Interval is the minimum interval between events with the same fingerprint, so an error storm does not
+	// flood GA. Defaults to DefaultExceptionInterval; a negative interval disables rate limiting.
+	Interval time.Duration
+
+	// Route, if set, returns the route template that handled the request. It defaults to the http.ServeMux
+	// pattern when the middleware wraps the mux, and the request path otherwise.
+	Route func(r *http.Request) string
+
+	// Repanic re-panics after a recovered panic is tracked, e.g. to let an outer recovery handler log it.
+	// Otherwise a 500 Internal Server Error is written if no response has been started.
+	Repanic bool
+
+	// Timeout bounds each send, defaults to DefaultSendTimeout.
+	Timeout time.Duration
+
+	// MaxInFlight bounds the number of events being sent at once, defaults to DefaultMaxInFlight.
+	MaxInFlight int
+
+	// OnError, if set, is called with errors from sending events, including ErrEventDropped.
+	OnError func(error)
+
+	// Sender, if set, sends the events instead of a sender created from Client, Timeout, MaxInFlight and
+	// OnError. Pass a caller-owned sender to call its Wait during graceful shutdown.
+	Sender *EventSender
+}
+
+// ExceptionMiddleware recovers panics and observes 5xx responses, sending an exception event for each with
+// the request's session. The description is the error class followed by a fingerprint: for panics the
+// panicking function and a hash of the function names on the stack, which is stable across deploys that do
+// not change the call path. Panics are marked fatal. The route and status code are added as page_path and
+// status_code. Identical fingerprints are rate limited, see ExceptionConfig.Interval.
+// http.ErrAbortHandler panics are re-panicked without being tracked. It panics if neither Sender nor Client
+// is set.
+func ExceptionMiddleware(config ExceptionConfig) func(http.Handler) http.Handler {
+	if config.Interval == 0 {
+		config.Interval = DefaultExceptionInterval
+	}
+	sender := config.Sender
+	if sender == nil {
+		sender = NewEventSender(EventSenderConfig{
+			Client:      config.Client,
+			Timeout:     config.Timeout,
+			MaxInFlight: config.MaxInFlight,
+			OnError:     config.OnError,
+		})
+	}
+	limiter := &fingerprintLimiter{interval: config.Interval, last: make(map[string]time.Time)}
+
+	track := func(r *http.Request, description string, fatal bool, status int) {
+		session, ok := SessionFromContext(r.Context())
+		if !ok {
+			session = ParseSessionFromRequest(r)
+		}
+		if session.ClientID == "" || !limiter.Allow(description, time.Now()) {
+			return
+		}
+		sender.Send(r.Context(), session, ExceptionEvent, map[string]string{
+			"description":   truncateString(description, maxParamValueLength),
+			"fatal":         strconv.FormatBool(fatal),
+			"page_path":     truncateString(config.route(r), maxParamValueLength),
+			StatusCodeParam: strconv.Itoa(status),
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				track(r, panicDescription(recovered), true, http.StatusInternalServerError)
+				if config.Repanic {
+					panic(recovered)
+				}
+				if rec.status == 0 {
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if status := rec.Status(); status >= http.StatusInternalServerError {
+				track(r, fmt.Sprintf("HTTP %d %s", status, config.route(r)), false, status)
+			}
+		})
+	}
+}
+
+func (config ExceptionConfig) route(r *http.Request) string {
+	if config.Route != nil {
+		if route := config.Route(r); route != "" {
+			return route
+		}
+	}
+	if route := routePattern(r); route != "" {
+		return route
+	}
+	return r.URL.Path
+}
+
+// panicDescription describes a recovered panic as its class, the panicking function and a stack fingerprint,
+// e.g. "runtime.boundsError at main.handler #1a2b3c4d". It must be called from the deferred recovery function.
+func panicDescription(recovered any) string {
+	class := fmt.Sprintf("%T", recovered)
+
+	pcs := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+
+	// skip the recovery frames up to runtime.gopanic, then hash the function names of the panicking stack,
+	// leaving out runtime frames such as runtime.panicIndex
+	h := fnv.New32a()
+	var function string
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			panicking = true
+		case panicking && !strings.HasPrefix(frame.Function, "runtime."):
+			if function == "" {
+				function = frame.Function
+			}
+			h.Write([]byte(frame.Function))
+			h.Write([]byte{'\n'})
+		}
+		if !more {
+			break
+		}
+	}
+
+	if function == "" {
+		return fmt.Sprintf("%s #%08x", class, h.Sum32())
+	}
+	return fmt.Sprintf("%s at %s #%08x", class, function, h.Sum32())
+}
+
+// fingerprintLimiter allows one event per fingerprint per interval.
+type fingerprintLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// Allow reports whether an event with the fingerprint may be sent at now, recording it if so.
+func (l *fingerprintLimiter) Allow(fingerprint string, now time.Time) bool {
+	if l.interval < 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.last[fingerprint]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+	l.last[fingerprint] = now
+
+	// forget fingerprints that can no longer be limited, so the map does not grow without bound
+	if len(l.last) > 1024 {
+		for key, last := range l.last {
+			if now.Sub(last) >= l.interval {
+				delete(l.last, key)
+			}
+		}
+	}
+	return true
+}
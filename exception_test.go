@@ -0,0 +1,121 @@
+package ga4m
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func panicIndex(w http.ResponseWriter, r *http.Request) {
+	var items []int
+	_ = items[len(r.URL.Path)]
+}
+
+func panicError(w http.ResponseWriter, r *http.Request) {
+	panic(errors.New("boom"))
+}
+
+func TestExceptionMiddleware_Panic(t *testing.T) {
+	client, payloads := recordingClient(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", panicIndex)
+	mux.HandleFunc("GET /orders/{id}", panicError)
+	sender := NewEventSender(EventSenderConfig{Client: client})
+	handler := ExceptionMiddleware(ExceptionConfig{Sender: sender})(mux)
+
+	// the same panic twice is rate limited, a different one is not
+	for _, target := range []string{"/items/1", "/items/2", "/orders/1"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	}
+
+	sender.Wait()
+	require.Len(t, payloads(), 2)
+
+	events := map[string]map[string]string{}
+	for _, payload := range payloads() {
+		event := payload.Events[0]
+		assert.Equal(t, ExceptionEvent, event.Name)
+		events[event.Params["page_path"]] = event.Params
+	}
+	require.Contains(t, events, "/items/{id}")
+	require.Contains(t, events, "/orders/{id}")
+
+	items := events["/items/{id}"]
+	assert.True(t, strings.HasPrefix(items["description"], "runtime.boundsError at github.com/agentstation/ga4m.panicIndex #"), items["description"])
+	assert.Equal(t, "true", items["fatal"])
+	assert.Equal(t, "500", items[StatusCodeParam])
+	assert.True(t, strings.HasPrefix(events["/orders/{id}"]["description"], "*errors.errorString at github.com/agentstation/ga4m.panicError #"))
+}
+
+func TestExceptionMiddleware_ServerError(t *testing.T) {
+	client, payloads := recordingClient(t)
+	handler := ExceptionMiddleware(ExceptionConfig{Client: client, Interval: -1})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+
+	for _, target := range []string{"/unavailable", "/unavailable", "/ok"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// rate limiting is disabled, so both 503s are tracked
+	assert.Eventually(t, func() bool { return len(payloads()) == 2 }, time.Second, 5*time.Millisecond)
+	event := payloads()[0].Events[0]
+	assert.Equal(t, "HTTP 503 /unavailable", event.Params["description"])
+	assert.Equal(t, "false", event.Params["fatal"])
+	assert.Equal(t, "503", event.Params[StatusCodeParam])
+}
+
+func TestExceptionMiddleware_Repanic(t *testing.T) {
+	client, payloads := recordingClient(t)
+	handler := ExceptionMiddleware(ExceptionConfig{Client: client, Repanic: true})(http.HandlerFunc(panicError))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	assert.Panics(t, func() { handler.ServeHTTP(httptest.NewRecorder(), req) })
+	assert.Eventually(t, func() bool { return len(payloads()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// http.ErrAbortHandler is re-panicked and not tracked
+	handler = ExceptionMiddleware(ExceptionConfig{Client: client})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { handler.ServeHTTP(httptest.NewRecorder(), req) })
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, payloads(), 1)
+}
+
+func TestPanicDescription_Stable(t *testing.T) {
+	describe := func() (description string) {
+		defer func() { description = panicDescription(recover()) }()
+		panicError(nil, nil)
+		return ""
+	}
+
+	first := describe()
+	assert.Equal(t, first, describe())
+	assert.Contains(t, first, "panicError")
+}
+
+func TestFingerprintLimiter(t *testing.T) {
+	limiter := &fingerprintLimiter{interval: time.Minute, last: make(map[string]time.Time)}
+	now := time.Unix(1731019235, 0)
+
+	assert.True(t, limiter.Allow("a", now))
+	assert.False(t, limiter.Allow("a", now.Add(30*time.Second)))
+	assert.True(t, limiter.Allow("b", now.Add(30*time.Second)))
+	assert.True(t, limiter.Allow("a", now.Add(time.Minute)))
+}
@@ -0,0 +1,30 @@
+module github.com/agentstation/ga4m/fiber
+
+go 1.23.1
+
+require (
+	github.com/agentstation/ga4m v0.2.0
+	github.com/gofiber/fiber/v2 v2.52.5
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.0.5 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/uuid v1.5.0 // indirect
+	github.com/klauspost/compress v1.17.0 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mattn/go-runewidth v0.0.15 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/rivo/uniseg v0.2.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasthttp v1.51.0 // indirect
+	github.com/valyala/tcplisten v1.0.0 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The replace directive is for development in this repository and is ignored by consumers of the module,
+// who get the required core version. See Releasing in the README.
+replace github.com/agentstation/ga4m => ../
@@ -0,0 +1,108 @@
+// Package ga4mfiber provides Fiber middleware for the ga4m Google Analytics 4 client.
+package ga4mfiber
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/agentstation/ga4m"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware extracts user Google Analytics session data from cookies and
+// stores it in the fiber context locals for later use, see GetSession.
+// The session is also stored in the user context, see ga4m.SessionFromContext
+func Middleware() fiber.Handler {
+	return MiddlewareWithConfig(ga4m.CookieMiddlewareConfig{})
+}
+
+// MiddlewareWithConfig returns a Middleware with config
+func MiddlewareWithConfig(config ga4m.CookieMiddlewareConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		session := config.ParseSession(newRequest(c))
+		c.Locals(ga4m.ContextKey, session)
+		c.SetUserContext(ga4m.NewContext(c.UserContext(), session))
+		return c.Next()
+	}
+}
+
+// GetSession returns the Google Analytics session stored in the fiber context locals by Middleware.
+func GetSession(c *fiber.Ctx) (ga4m.Session, bool) {
+	session, ok := c.Locals(ga4m.ContextKey).(ga4m.Session)
+	return session, ok
+}
+
+// ParseSession returns the Google Analytics session stored in the fiber context locals by Middleware,
+// or parses it from the request cookies if the middleware is not in use.
+func ParseSession(c *fiber.Ctx) ga4m.Session {
+	if session, ok := GetSession(c); ok {
+		return session
+	}
+	return ga4m.ParseSessionFromRequest(newRequest(c))
+}
+
+// PageViewConfig configures the Fiber page_view middleware.
+type PageViewConfig struct {
+	// Next defines a function to skip the middleware when it returns true, in addition to PageViewConfig.Matchers.
+	Next func(c *fiber.Ctx) bool
+
+	ga4m.PageViewConfig
+}
+
+// PageViewMiddleware sends a page_view (or PageViewConfig.EventName) event after each matching response.
+// The route template, such as /users/:id, is used as the page path, and the status code and latency are added
+// as parameters. Events are sent in the background so responses are never delayed; set PageViewConfig.Sender
+// to wait for them on shutdown. Use after Middleware, or the session is parsed from the request cookies.
+func PageViewMiddleware(config PageViewConfig) fiber.Handler {
+	tracker := ga4m.NewPageViewTracker(config.PageViewConfig)
+
+	return func(c *fiber.Ctx) error {
+		if config.Next != nil && config.Next(c) {
+			return c.Next()
+		}
+		r := newRequest(c)
+		if !tracker.Match(r) {
+			return c.Next()
+		}
+
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(http.StatusInternalServerError)
+			}
+		}
+		tracker.Track(r, c.Route().Path, c.Response().StatusCode(), time.Since(start))
+		return nil
+	}
+}
+
+// newRequest returns a net/http request with the method, URL, headers and remote address of the fiber request.
+// Values are copied because fasthttp reuses its buffers once the handler returns, and events are sent later.
+func newRequest(c *fiber.Ctx) *http.Request {
+	r := &http.Request{
+		Method:     strings.Clone(c.Method()),
+		Host:       strings.Clone(c.Hostname()),
+		RequestURI: strings.Clone(c.OriginalURL()),
+		RemoteAddr: c.Context().RemoteAddr().String(),
+		Header:     make(http.Header),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	r = r.WithContext(c.UserContext())
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		r.URL = u
+	} else {
+		r.URL = &url.URL{Path: strings.Clone(c.Path())}
+	}
+	if c.Protocol() == "https" {
+		r.TLS = &tls.ConnectionState{}
+	}
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		r.Header.Add(string(key), string(value))
+	})
+	return r
+}
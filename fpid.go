@@ -0,0 +1,186 @@
+package ga4m
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultFPIDCookieName is the default name of the first-party ID cookie
+	DefaultFPIDCookieName = "FPID"
+
+	// fpidPrefix prefixes unencrypted FPID cookie values (FPID1.476555468.1726969270)
+	fpidPrefix = "FPID1."
+)
+
+// FPIDConfig configures the server-set, HttpOnly first-party ID (FPID) cookie. Unlike the _ga cookie set by
+// gtag.js, it is not subject to the 7 day lifetime cap browsers such as Safari apply to script-set cookies.
+// The cookie's lifetime is refreshed on every request by FPIDMiddleware; the CookieOptions Format is not used.
+type FPIDConfig struct {
+	CookieOptions
+
+	CookieName string // Cookie name, defaults to DefaultFPIDCookieName
+
+	// Key, if set, encrypts the client ID in the cookie with AES-GCM. It must be 16, 24 or 32 bytes long;
+	// FPIDMiddleware panics otherwise.
+	Key []byte
+}
+
+// ClientID returns the client ID stored in the request's FPID cookie, if present and valid.
+func (c FPIDConfig) ClientID(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(c.cookieName())
+	if err != nil {
+		return "", false
+	}
+	clientID, err := c.decode(cookie.Value)
+	if err != nil {
+		return "", false
+	}
+	return clientID, true
+}
+
+// ParseSession parses the Google Analytics session from a request, preferring the client ID from the FPID cookie
+// over the one in the _ga cookie. It reports whether the client ID came from the FPID cookie.
+func (c FPIDConfig) ParseSession(r *http.Request) (Session, bool) {
+	session := parseSessionCookies(r)
+	clientID, ok := c.ClientID(r)
+	if ok {
+		applyClientID(&session, clientID)
+	}
+	return session, ok
+}
+
+// Cookie returns the FPID cookie storing the client ID.
+func (c FPIDConfig) Cookie(clientID string) (*http.Cookie, error) {
+	value, err := c.encode(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie := c.cookie(c.cookieName(), value)
+	cookie.HttpOnly = true
+	return cookie, nil
+}
+
+// FPIDMiddleware issues and refreshes the FPID cookie. The client ID is taken from an existing FPID cookie,
+// upgraded from the _ga cookie, or newly generated, and the cookie is rewritten with a fresh expiry on every
+// response. The client ID is stored in the request context, so ParseSessionFromRequest, ParseStreamSession and
+// the middleware that parse sessions from cookies use it even when the cookie is encrypted or renamed. A newly
+// issued cookie is also added to a clone of the request passed to the next handler. It panics if the Key is not a valid AES key length.
+func FPIDMiddleware(config FPIDConfig) func(http.Handler) http.Handler {
+	if len(config.Key) > 0 {
+		if _, err := config.aead(); err != nil {
+			panic(fmt.Sprintf("ga4m: %v", err))
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientID, ok := config.ClientID(r)
+			if !ok {
+				clientID = parseSessionCookies(r).ClientID
+				if clientID == "" {
+					clientID = NewClientID(time.Now())
+				}
+			}
+
+			if cookie, err := config.Cookie(clientID); err == nil {
+				http.SetCookie(w, cookie)
+				ctx := context.WithValue(r.Context(), fpidContextKey{}, clientID)
+				if ok {
+					r = r.WithContext(ctx)
+				} else {
+					// add the new cookie to a clone so FPIDConfig.ClientID sees it without modifying the caller's request
+					r = r.Clone(ctx)
+					r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// applyClientID replaces the session's client ID with one from an FPID cookie or identity header.
+func applyClientID(session *Session, clientID string) {
+	session.ClientID = clientID
+	if session.ClientVersion == "" {
+		session.ClientVersion = "1"
+	}
+	session.FirstVisit = time.Time{}
+	if _, ts, ok := strings.Cut(clientID, "."); ok {
+		if ts, err := strconv.ParseInt(ts, 10, 64); err == nil && ts > 0 && ts <= time.Now().Unix() {
+			session.FirstVisit = time.Unix(ts, 0)
+		}
+	}
+}
+
+func (c FPIDConfig) cookieName() string {
+	if c.CookieName == "" {
+		return DefaultFPIDCookieName
+	}
+	return c.CookieName
+}
+
+// encode returns the cookie value for the client ID, encrypting it if a key is configured.
+func (c FPIDConfig) encode(clientID string) (string, error) {
+	if clientID == "" {
+		return "", fmt.Errorf("client ID cannot be empty")
+	}
+	if len(c.Key) == 0 {
+		return fpidPrefix + clientID, nil
+	}
+
+	gcm, err := c.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(clientID), []byte(c.cookieName()))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// decode returns the client ID stored in a cookie value, decrypting it if a key is configured.
+func (c FPIDConfig) decode(value string) (string, error) {
+	if len(c.Key) == 0 {
+		clientID, ok := strings.CutPrefix(value, fpidPrefix)
+		if !ok || clientID == "" {
+			return "", fmt.Errorf("invalid FPID cookie value")
+		}
+		return clientID, nil
+	}
+
+	gcm, err := c.aead()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid FPID cookie value")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	clientID, err := gcm.Open(nil, nonce, ciphertext, []byte(c.cookieName()))
+	if err != nil || len(clientID) == 0 {
+		return "", fmt.Errorf("invalid FPID cookie value")
+	}
+	return string(clientID), nil
+}
+
+func (c FPIDConfig) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FPID key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,155 @@
+package ga4m
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFPIDConfig_Cookie(t *testing.T) {
+	config := FPIDConfig{CookieOptions: CookieOptions{Domain: ".example.com", Secure: true}}
+
+	cookie, err := config.Cookie("476555468.1726969270")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultFPIDCookieName, cookie.Name)
+	assert.Equal(t, "FPID1.476555468.1726969270", cookie.Value)
+	assert.Equal(t, ".example.com", cookie.Domain)
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, int(DefaultCookieMaxAge/time.Second), cookie.MaxAge)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+
+	_, err = config.Cookie("")
+	assert.Error(t, err)
+}
+
+func TestFPIDConfig_Encrypted(t *testing.T) {
+	config := FPIDConfig{CookieName: "_fpid", Key: []byte("0123456789abcdef0123456789abcdef")}
+
+	cookie, err := config.Cookie("476555468.1726969270")
+	require.NoError(t, err)
+	assert.Equal(t, "_fpid", cookie.Name)
+	assert.NotContains(t, cookie.Value, "476555468")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	clientID, ok := config.ClientID(req)
+	assert.True(t, ok)
+	assert.Equal(t, "476555468.1726969270", clientID)
+
+	// a different key cannot read the cookie
+	other := FPIDConfig{CookieName: "_fpid", Key: []byte("fedcba9876543210fedcba9876543210")}
+	_, ok = other.ClientID(req)
+	assert.False(t, ok)
+
+	// an invalid key length is reported
+	_, err = FPIDConfig{Key: []byte("short")}.Cookie("476555468.1726969270")
+	assert.Error(t, err)
+	assert.Panics(t, func() { FPIDMiddleware(FPIDConfig{Key: []byte("short")}) })
+}
+
+func TestFPIDConfig_ParseSession(t *testing.T) {
+	config := FPIDConfig{}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "ABC123", Value: "GS1.1.1731019235.2.1.1731019762.0.0.0"})
+
+	// without an FPID cookie the _ga client ID is used
+	session, fromFPID := config.ParseSession(req)
+	assert.False(t, fromFPID)
+	assert.Equal(t, "71807069.1731019235", session.ClientID)
+
+	// the FPID cookie is preferred, keeping the session data
+	req.AddCookie(&http.Cookie{Name: DefaultFPIDCookieName, Value: "FPID1.476555468.1726969270"})
+	session, fromFPID = config.ParseSession(req)
+	assert.True(t, fromFPID)
+	assert.Equal(t, "476555468.1726969270", session.ClientID)
+	assert.Equal(t, time.Unix(1726969270, 0), session.FirstVisit)
+	assert.Equal(t, "1731019235", session.SessionID)
+
+	// ParseSessionFromRequest prefers a default FPID cookie too
+	assert.Equal(t, "476555468.1726969270", ParseSessionFromRequest(req).ClientID)
+	assert.Equal(t, "476555468.1726969270", ParseStreamSession(req, "G-ABC123").ClientID)
+}
+
+func TestFPIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		cookies        []*http.Cookie
+		expectedClient string
+	}{
+		{
+			name:           "Refreshes Existing FPID",
+			cookies:        []*http.Cookie{{Name: DefaultFPIDCookieName, Value: "FPID1.476555468.1726969270"}},
+			expectedClient: "476555468.1726969270",
+		},
+		{
+			name:           "Upgrades _ga Cookie",
+			cookies:        []*http.Cookie{{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"}},
+			expectedClient: "71807069.1731019235",
+		},
+		{
+			name: "Issues New FPID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, cookie := range tt.cookies {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+
+			var handlerClientID, fpidClientID string
+			handler := FPIDMiddleware(FPIDConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerClientID = ParseSessionFromRequest(r).ClientID
+				fpidClientID, _ = FPIDConfig{}.ClientID(r)
+			}))
+			handler.ServeHTTP(rec, req)
+			assert.Len(t, req.Cookies(), len(tt.cookies), "the caller's request must not be modified")
+
+			cookies := rec.Result().Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, DefaultFPIDCookieName, cookies[0].Name)
+			assert.True(t, cookies[0].HttpOnly)
+			require.NotEmpty(t, handlerClientID)
+			assert.Equal(t, "FPID1."+handlerClientID, cookies[0].Value)
+			assert.Equal(t, handlerClientID, fpidClientID)
+			if tt.expectedClient != "" {
+				assert.Equal(t, tt.expectedClient, handlerClientID)
+			}
+		})
+	}
+}
+
+func TestFPIDMiddleware_EncryptedCookieMiddleware(t *testing.T) {
+	config := FPIDConfig{CookieName: "_fpid", Key: []byte("0123456789abcdef0123456789abcdef")}
+
+	for _, cookieConfig := range []CookieMiddlewareConfig{{}, {MeasurementID: "G-ABC123"}} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		var session Session
+		handler := FPIDMiddleware(config)(GoogleAnalyticsCookieMiddlewareWithConfig(cookieConfig)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				session, _ = SessionFromContext(r.Context())
+			}),
+		))
+		handler.ServeHTTP(rec, req)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		issued := httptest.NewRequest(http.MethodGet, "/", nil)
+		issued.AddCookie(cookies[0])
+		clientID, ok := config.ClientID(issued)
+		require.True(t, ok)
+		assert.Equal(t, clientID, session.ClientID)
+	}
+}
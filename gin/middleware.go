@@ -0,0 +1,72 @@
+// Package ga4mgin provides Gin middleware for the ga4m Google Analytics 4 client.
+package ga4mgin
+
+import (
+	"time"
+
+	"github.com/agentstation/ga4m"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware extracts user Google Analytics session data from cookies and
+// stores it in the gin context for later use, see GetSession.
+// The session is also stored in the request context, see ga4m.SessionFromContext
+func Middleware() gin.HandlerFunc {
+	return MiddlewareWithConfig(ga4m.CookieMiddlewareConfig{})
+}
+
+// MiddlewareWithConfig returns a Middleware with config
+func MiddlewareWithConfig(config ga4m.CookieMiddlewareConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := config.ParseSession(c.Request)
+		c.Set(ga4m.ContextKey, session)
+		c.Request = c.Request.WithContext(ga4m.NewContext(c.Request.Context(), session))
+		c.Next()
+	}
+}
+
+// GetSession returns the Google Analytics session stored in the gin context by Middleware.
+func GetSession(c *gin.Context) (ga4m.Session, bool) {
+	value, ok := c.Get(ga4m.ContextKey)
+	if !ok {
+		return ga4m.Session{}, false
+	}
+	session, ok := value.(ga4m.Session)
+	return session, ok
+}
+
+// ParseSession returns the Google Analytics session stored in the gin context by Middleware,
+// or parses it from the request cookies if the middleware is not in use.
+func ParseSession(c *gin.Context) ga4m.Session {
+	if session, ok := GetSession(c); ok {
+		return session
+	}
+	return ga4m.ParseSessionFromRequest(c.Request)
+}
+
+// PageViewConfig configures the Gin page_view middleware.
+type PageViewConfig struct {
+	// Skipper defines a function to skip the middleware, in addition to PageViewConfig.Matchers.
+	Skipper func(c *gin.Context) bool
+
+	ga4m.PageViewConfig
+}
+
+// PageViewMiddleware sends a page_view (or PageViewConfig.EventName) event after each matching response.
+// The route template, such as /users/:id, is used as the page path, and the status code and latency are added
+// as parameters. Events are sent in the background so responses are never delayed; set PageViewConfig.Sender
+// to wait for them on shutdown. Use after Middleware, or the session is parsed from the request cookies.
+func PageViewMiddleware(config PageViewConfig) gin.HandlerFunc {
+	tracker := ga4m.NewPageViewTracker(config.PageViewConfig)
+
+	return func(c *gin.Context) {
+		if (config.Skipper != nil && config.Skipper(c)) || !tracker.Match(c.Request) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		tracker.Track(c.Request, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
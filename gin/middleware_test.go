@@ -0,0 +1,135 @@
+package ga4mgin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/agentstation/ga4m"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHTTPClient records the payloads sent by a client.
+type mockHTTPClient struct {
+	mu       sync.Mutex
+	payloads []ga4m.AnalyticsEvent
+}
+
+func (m *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	var payload ga4m.AnalyticsEvent
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.payloads = append(m.payloads, payload)
+	m.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func (m *mockHTTPClient) Payloads() []ga4m.AnalyticsEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ga4m.AnalyticsEvent(nil), m.payloads...)
+}
+
+func addCookies(req *http.Request) {
+	req.AddCookie(&http.Cookie{Name: "_ga", Value: "GA1.1.71807069.1731019235"})
+	req.AddCookie(&http.Cookie{Name: "_ga_OTHER", Value: "GS1.1.1731000000.9.1.1731019762.0.0.0"})
+	req.AddCookie(&http.Cookie{Name: "_ga_ABC123", Value: "GS1.1.1731019235.2.1.1731019762.0.0.0"})
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name              string
+		config            ga4m.CookieMiddlewareConfig
+		expectedSessionID string
+	}{
+		{"First Session Cookie", ga4m.CookieMiddlewareConfig{}, "1731000000"},
+		{"Configured Stream", ga4m.CookieMiddlewareConfig{MeasurementID: "G-ABC123"}, "1731019235"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var session, fromRequest ga4m.Session
+			var ok, okRequest bool
+			r := gin.New()
+			r.Use(MiddlewareWithConfig(tt.config))
+			r.GET("/", func(c *gin.Context) {
+				session, ok = GetSession(c)
+				fromRequest, okRequest = ga4m.SessionFromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			addCookies(req)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.True(t, ok)
+			assert.True(t, okRequest)
+			assert.Equal(t, session, fromRequest)
+			assert.Equal(t, "71807069.1731019235", session.ClientID)
+			assert.Equal(t, tt.expectedSessionID, session.SessionID)
+		})
+	}
+}
+
+func TestParseSession(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	addCookies(req)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+
+	// without the middleware the session is parsed from the cookies
+	_, ok := GetSession(c)
+	assert.False(t, ok)
+	assert.Equal(t, "71807069.1731019235", ParseSession(c).ClientID)
+}
+
+func TestPageViewMiddleware(t *testing.T) {
+	httpClient := &mockHTTPClient{}
+	client := ga4m.NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(httpClient)
+	sender := ga4m.NewEventSender(ga4m.EventSenderConfig{Client: client})
+	config := PageViewConfig{
+		Skipper:        func(c *gin.Context) bool { return c.FullPath() == "/internal" },
+		PageViewConfig: ga4m.PageViewConfig{Sender: sender},
+	}
+
+	r := gin.New()
+	r.Use(Middleware(), PageViewMiddleware(config))
+	r.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/missing/:id", func(c *gin.Context) { c.AbortWithStatus(http.StatusNotFound) })
+	r.GET("/internal", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/users", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	for _, route := range []struct{ method, target string }{
+		{http.MethodGet, "/users/42"},
+		{http.MethodGet, "/missing/7"},
+		{http.MethodGet, "/internal"},
+		{http.MethodPost, "/users"},
+	} {
+		req := httptest.NewRequest(route.method, route.target, nil)
+		addCookies(req)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// sends are asynchronous, wait for both tracked requests
+	sender.Wait()
+	assert.Len(t, httpClient.Payloads(), 2)
+
+	statuses := map[string]string{}
+	for _, payload := range httpClient.Payloads() {
+		event := payload.Events[0]
+		assert.Equal(t, ga4m.PageViewEvent, event.Name)
+		statuses[event.Params["page_path"]] = event.Params[ga4m.StatusCodeParam]
+	}
+	assert.Equal(t, map[string]string{"/users/:id": "200", "/missing/:id": "404"}, statuses)
+}
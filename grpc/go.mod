@@ -0,0 +1,24 @@
+module github.com/agentstation/ga4m/grpc
+
+go 1.23.1
+
+require (
+	github.com/agentstation/ga4m v0.2.0
+	github.com/stretchr/testify v1.9.0
+	google.golang.org/grpc v1.67.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The replace directive is for development in this repository and is ignored by consumers of the module,
+// who get the required core version. See Releasing in the README.
+replace github.com/agentstation/ga4m => ../
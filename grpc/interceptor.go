@@ -0,0 +1,211 @@
+// Package ga4mgrpc provides gRPC server interceptors for the ga4m Google Analytics 4 client.
+package ga4mgrpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentstation/ga4m"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// RPCEvent is the default name of the event sent for each tracked RPC
+	RPCEvent = "grpc_request"
+
+	// ServiceParam is the parameter name for the RPC's fully qualified service, e.g. "shop.v1.CartService"
+	ServiceParam = "rpc_service"
+
+	// MethodParam is the parameter name for the RPC's method, e.g. "AddItem"
+	MethodParam = "rpc_method"
+
+	// CodeParam is the parameter name for the RPC's status code, e.g. "OK" or "NotFound"
+	CodeParam = "rpc_code"
+)
+
+// healthAndReflectionServices are the services skipped by DefaultFilters.
+var healthAndReflectionServices = []string{
+	"grpc.health.v1.Health",
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.reflection.v1alpha.ServerReflection",
+}
+
+// MethodFilter reports whether an RPC, identified by its full method name such as
+// "/shop.v1.CartService/AddItem", should be tracked.
+type MethodFilter func(fullMethod string) bool
+
+// SkipServices matches RPCs that do not belong to any of the fully qualified services.
+func SkipServices(services ...string) MethodFilter {
+	return func(fullMethod string) bool {
+		service, _ := splitMethodName(fullMethod)
+		for _, skip := range services {
+			if service == skip {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// DefaultFilters are the filters used when Config.Filters is empty: RPCs that are not for the
+// gRPC health checking or server reflection services.
+func DefaultFilters() []MethodFilter {
+	return []MethodFilter{SkipServices(healthAndReflectionServices...)}
+}
+
+// Config configures the gRPC server interceptors.
+type Config struct {
+	// MeasurementID selects the GA4 stream whose session cookie is used, e.g. "G-ABC123".
+	// If empty, the first _ga_* cookie found is used.
+	MeasurementID string
+
+	// Client, if set, sends an event for each tracked RPC. Without it or Sender the interceptors only extract
+	// the session.
+	Client *ga4m.AnalyticsClient
+
+	// EventName is the event sent for each tracked RPC, defaults to RPCEvent.
+	EventName string
+
+	// Filters must all match for an RPC to be tracked, defaults to DefaultFilters.
+	Filters []MethodFilter
+
+	// Params, if set, returns extra parameters for the RPC's event. They win over derived parameters.
+	Params func(ctx context.Context, fullMethod string) map[string]string
+
+	// Timeout bounds each send, defaults to ga4m.DefaultSendTimeout.
+	Timeout time.Duration
+
+	// MaxInFlight bounds the number of events being sent at once, defaults to ga4m.DefaultMaxInFlight.
+	// Events beyond the limit are dropped rather than blocking the RPC.
+	MaxInFlight int
+
+	// OnError, if set, is called with errors from sending events, including ga4m.ErrEventDropped.
+	OnError func(error)
+
+	// Sender, if set, sends the events instead of a sender created from Client, Timeout, MaxInFlight and
+	// OnError. Pass a caller-owned sender to call its Wait during graceful shutdown.
+	Sender *ga4m.EventSender
+}
+
+// UnaryServerInterceptor parses the Google Analytics session from the incoming metadata and stores it in the
+// context, see ga4m.SessionFromContext. The session is parsed from the cookie metadata forwarded from the
+// browser, with the x-ga-client-id and x-ga-session-id keys taking precedence. A user ID in the x-ga-user-id key
+// is stored too, see ga4m.UserIDFromContext. If Config.Client or Config.Sender is set, an
+// event with the service, method, status code and latency is sent in the background for each tracked RPC.
+func UnaryServerInterceptor(config Config) grpc.UnaryServerInterceptor {
+	i := newInterceptor(config)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, session := i.session(ctx)
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		i.track(ctx, session, info.FullMethod, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor. The event for a tracked
+// stream is sent when the stream completes.
+func StreamServerInterceptor(config Config) grpc.StreamServerInterceptor {
+	i := newInterceptor(config)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, session := i.session(ss.Context())
+		start := time.Now()
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		i.track(ctx, session, info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+type interceptor struct {
+	config Config
+	sender *ga4m.EventSender
+}
+
+func newInterceptor(config Config) *interceptor {
+	if config.EventName == "" {
+		config.EventName = RPCEvent
+	}
+	if len(config.Filters) == 0 {
+		config.Filters = DefaultFilters()
+	}
+
+	i := &interceptor{config: config, sender: config.Sender}
+	if i.sender == nil && config.Client != nil {
+		i.sender = ga4m.NewEventSender(ga4m.EventSenderConfig{
+			Client:      config.Client,
+			Timeout:     config.Timeout,
+			MaxInFlight: config.MaxInFlight,
+			OnError:     config.OnError,
+		})
+	}
+	return i
+}
+
+// session parses the session from the incoming metadata and returns it with a context carrying it and any
+// user ID.
+func (i *interceptor) session(ctx context.Context) (context.Context, ga4m.Session) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	config := ga4m.CookieMiddlewareConfig{MeasurementID: i.config.MeasurementID}
+	session := config.ParseSessionFromHeader(header)
+	if userID := header.Get(ga4m.UserIDHeader); userID != "" {
+		ctx = ga4m.NewUserIDContext(ctx, userID)
+	}
+	return ga4m.NewContext(ctx, session), session
+}
+
+// track sends the event for a completed RPC if tracking is enabled and the RPC matches the filters.
+func (i *interceptor) track(ctx context.Context, session ga4m.Session, fullMethod string, err error, latency time.Duration) {
+	if i.sender == nil || session.ClientID == "" {
+		return
+	}
+	for _, filter := range i.config.Filters {
+		if !filter(fullMethod) {
+			return
+		}
+	}
+
+	service, method := splitMethodName(fullMethod)
+	params := map[string]string{
+		ServiceParam:      service,
+		MethodParam:       method,
+		CodeParam:         status.Code(err).String(),
+		ga4m.LatencyParam: strconv.FormatInt(latency.Milliseconds(), 10),
+	}
+	if i.config.Params != nil {
+		for k, v := range i.config.Params(ctx, fullMethod) {
+			params[k] = v
+		}
+	}
+	i.sender.Send(ctx, session, i.config.EventName, params)
+}
+
+// splitMethodName splits a full method name such as "/shop.v1.CartService/AddItem" into its service and method.
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndexByte(fullMethod, '/'); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+// serverStream is a grpc.ServerStream whose context carries the session.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,195 @@
+package ga4mgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agentstation/ga4m"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// mockHTTPClient records the payloads sent by a client.
+type mockHTTPClient struct {
+	mu       sync.Mutex
+	payloads []ga4m.AnalyticsEvent
+}
+
+func (m *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	var payload ga4m.AnalyticsEvent
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.payloads = append(m.payloads, payload)
+	m.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func (m *mockHTTPClient) Payloads() []ga4m.AnalyticsEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ga4m.AnalyticsEvent(nil), m.payloads...)
+}
+
+// mockServerStream is a grpc.ServerStream with only a context.
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *mockServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUnaryServerInterceptor_Session(t *testing.T) {
+	tests := []struct {
+		name              string
+		config            Config
+		md                metadata.MD
+		expectedClientID  string
+		expectedSessionID string
+		expectedUserID    string
+	}{
+		{
+			name:              "Cookie Metadata",
+			md:                metadata.Pairs("cookie", "_ga=GA1.1.71807069.1731019235; _ga_OTHER=GS1.1.1731000000.9.1.1731019762.0.0.0"),
+			expectedClientID:  "71807069.1731019235",
+			expectedSessionID: "1731000000",
+		},
+		{
+			name:   "Configured Stream",
+			config: Config{MeasurementID: "G-ABC123"},
+			md: metadata.Pairs(
+				"cookie", "_ga=GA1.1.71807069.1731019235; _ga_OTHER=GS1.1.1731000000.9.1.1731019762.0.0.0",
+				"cookie", "_ga_ABC123=GS1.1.1731019235.2.1.1731019762.0.0.0",
+			),
+			expectedClientID:  "71807069.1731019235",
+			expectedSessionID: "1731019235",
+		},
+		{
+			name:              "Identity Metadata",
+			md:                metadata.Pairs("x-ga-client-id", "476555468.1726969270", "x-ga-session-id", "1731019235", "x-ga-user-id", "user_42"),
+			expectedClientID:  "476555468.1726969270",
+			expectedSessionID: "1731019235",
+			expectedUserID:    "user_42",
+		},
+		{
+			name: "No Metadata",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			var session ga4m.Session
+			var userID string
+			var ok bool
+			_, err := UnaryServerInterceptor(tt.config)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/shop.v1.CartService/AddItem"},
+				func(ctx context.Context, req any) (any, error) {
+					session, ok = ga4m.SessionFromContext(ctx)
+					userID, _ = ga4m.UserIDFromContext(ctx)
+					return nil, nil
+				})
+
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expectedClientID, session.ClientID)
+			assert.Equal(t, tt.expectedSessionID, session.SessionID)
+			assert.Equal(t, tt.expectedUserID, userID)
+		})
+	}
+}
+
+func TestUnaryServerInterceptor_Tracking(t *testing.T) {
+	httpClient := &mockHTTPClient{}
+	client := ga4m.NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(httpClient)
+	sender := ga4m.NewEventSender(ga4m.EventSenderConfig{Client: client})
+	interceptor := UnaryServerInterceptor(Config{Sender: sender})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-ga-client-id", "476555468.1726969270"))
+	calls := []struct {
+		method string
+		err    error
+	}{
+		{"/shop.v1.CartService/AddItem", nil},
+		{"/shop.v1.CartService/RemoveItem", status.Error(codes.NotFound, "item not found")},
+		{"/grpc.health.v1.Health/Check", nil},
+		{"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", nil},
+	}
+	for _, call := range calls {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: call.method}, func(ctx context.Context, req any) (any, error) {
+			return nil, call.err
+		})
+		assert.Equal(t, call.err, err)
+	}
+
+	// sends are asynchronous, wait for both tracked RPCs
+	sender.Wait()
+	assert.Len(t, httpClient.Payloads(), 2)
+
+	rpcCodes := map[string]string{}
+	for _, payload := range httpClient.Payloads() {
+		event := payload.Events[0]
+		assert.Equal(t, RPCEvent, event.Name)
+		assert.Equal(t, "476555468.1726969270", payload.ClientID)
+		assert.Equal(t, "shop.v1.CartService", event.Params[ServiceParam])
+		assert.Contains(t, event.Params, ga4m.LatencyParam)
+		rpcCodes[event.Params[MethodParam]] = event.Params[CodeParam]
+	}
+	assert.Equal(t, map[string]string{"AddItem": "OK", "RemoveItem": "NotFound"}, rpcCodes)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	httpClient := &mockHTTPClient{}
+	client := ga4m.NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(httpClient)
+	interceptor := StreamServerInterceptor(Config{
+		Client:    client,
+		EventName: "rpc_stream",
+		Params: func(ctx context.Context, fullMethod string) map[string]string {
+			return map[string]string{"tenant": "acme"}
+		},
+	})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("cookie", "_ga=GA1.1.71807069.1731019235"))
+	var session ga4m.Session
+	err := interceptor(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/chat.v1.ChatService/Stream"},
+		func(srv any, stream grpc.ServerStream) error {
+			session, _ = ga4m.SessionFromContext(stream.Context())
+			return nil
+		})
+
+	require.NoError(t, err)
+	assert.Equal(t, "71807069.1731019235", session.ClientID)
+	assert.Eventually(t, func() bool { return len(httpClient.Payloads()) == 1 }, time.Second, 5*time.Millisecond)
+	event := httpClient.Payloads()[0].Events[0]
+	assert.Equal(t, "rpc_stream", event.Name)
+	assert.Equal(t, "Stream", event.Params[MethodParam])
+	assert.Equal(t, "acme", event.Params["tenant"])
+}
+
+func TestSplitMethodName(t *testing.T) {
+	service, method := splitMethodName("/shop.v1.CartService/AddItem")
+	assert.Equal(t, "shop.v1.CartService", service)
+	assert.Equal(t, "AddItem", method)
+
+	service, method = splitMethodName("malformed")
+	assert.Equal(t, "unknown", service)
+	assert.Equal(t, "malformed", method)
+}
@@ -0,0 +1,54 @@
+package ga4m
+
+import (
+	"net/http"
+)
+
+const (
+	// ClientIDHeader carries the Google Analytics client ID between services, e.g. "476555468.1726969270".
+	// As gRPC metadata the key is lowercase, x-ga-client-id.
+	ClientIDHeader = "X-GA-Client-ID"
+
+	// SessionIDHeader carries the Google Analytics session ID between services, e.g. "1731019235".
+	// As gRPC metadata the key is lowercase, x-ga-session-id.
+	SessionIDHeader = "X-GA-Session-ID"
+
+	// UserIDHeader carries the user ID between services.
+	// As gRPC metadata the key is lowercase, x-ga-user-id.
+	UserIDHeader = "X-GA-User-ID"
+)
+
+// ParseSessionFromHeader parses the Google Analytics session from the Cookie headers in h, then applies the
+// ClientIDHeader and SessionIDHeader values, which take precedence over the cookies.
+func ParseSessionFromHeader(h http.Header) Session {
+	return CookieMiddlewareConfig{}.ParseSessionFromHeader(h)
+}
+
+// ParseSessionFromHeader parses the session for the configured stream from the Cookie headers in h, then
+// applies the ClientIDHeader and SessionIDHeader values, which take precedence over the cookies.
+func (config CookieMiddlewareConfig) ParseSessionFromHeader(h http.Header) Session {
+	session := config.ParseSession(&http.Request{Header: h})
+	if clientID := h.Get(ClientIDHeader); clientID != "" {
+		applyClientID(&session, clientID)
+	}
+	if sessionID := h.Get(SessionIDHeader); sessionID != "" {
+		session.SessionID = sessionID
+	}
+	return session
+}
+
+// IdentityMiddleware rebuilds the Google Analytics identity propagated by Transport from the request headers
+// and stores it in the request context: the session from ParseSessionFromHeader, see SessionFromContext, and
+// the UserIDHeader value, see UserIDFromContext. The headers are trusted as sent, so use it only on internal
+// services that cannot be reached directly by clients.
+func IdentityMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewContext(r.Context(), ParseSessionFromHeader(r.Header))
+			if userID := r.Header.Get(UserIDHeader); userID != "" {
+				ctx = NewUserIDContext(ctx, userID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package ga4m
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSessionFromHeader(t *testing.T) {
+	tests := []struct {
+		name              string
+		header            map[string]string
+		expectedClientID  string
+		expectedSessionID string
+	}{
+		{
+			name:              "Cookie Header",
+			header:            map[string]string{"Cookie": "_ga=GA1.1.71807069.1731019235; _ga_ABC123=GS1.1.1731019235.2.1.1731019762.0.0.0"},
+			expectedClientID:  "71807069.1731019235",
+			expectedSessionID: "1731019235",
+		},
+		{
+			name:              "Identity Headers",
+			header:            map[string]string{ClientIDHeader: "476555468.1726969270", SessionIDHeader: "1731000000"},
+			expectedClientID:  "476555468.1726969270",
+			expectedSessionID: "1731000000",
+		},
+		{
+			name: "Identity Headers Win",
+			header: map[string]string{
+				"Cookie":        "_ga=GA1.1.71807069.1731019235; _ga_ABC123=GS1.1.1731019235.2.1.1731019762.0.0.0",
+				ClientIDHeader:  "476555468.1726969270",
+				SessionIDHeader: "1731000000",
+			},
+			expectedClientID:  "476555468.1726969270",
+			expectedSessionID: "1731000000",
+		},
+		{
+			name: "Empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+
+			session := ParseSessionFromHeader(header)
+			assert.Equal(t, tt.expectedClientID, session.ClientID)
+			assert.Equal(t, tt.expectedSessionID, session.SessionID)
+		})
+	}
+}
+
+func TestParseSessionFromHeader_FirstVisit(t *testing.T) {
+	header := make(http.Header)
+	header.Set(ClientIDHeader, "476555468.1726969270")
+
+	session := ParseSessionFromHeader(header)
+	assert.Equal(t, time.Unix(1726969270, 0), session.FirstVisit)
+	assert.Equal(t, "1", session.ClientVersion)
+}
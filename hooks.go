@@ -0,0 +1,65 @@
+package ga4m
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDropPayload is returned by a BeforeSendHook to drop the payload without sending it.
+// SendEvents then returns nil and no AfterSendHook is called.
+var ErrDropPayload = errors.New("drop payload")
+
+// BeforeSendHook inspects a payload after it is built and before it is sent. It may modify the payload,
+// e.g. to add tenant parameters to every event, or return ErrDropPayload to drop it, e.g. for internal test
+// users. Any other error stops the send and is returned by SendEvents. ctx is the context set with WithContext.
+type BeforeSendHook func(ctx context.Context, payload *AnalyticsEvent) error
+
+// SendResult describes the outcome of sending a payload, see AfterSendHook.
+type SendResult struct {
+	// Payload is the payload as sent, after any BeforeSendHook changes.
+	Payload AnalyticsEvent
+
+	// StatusCode is the status of the last HTTP response received, or 0 if none was received. When the
+	// payload is split across several requests, it is the status of the request that failed, if any.
+	StatusCode int
+
+	// Latency is the time taken to send the payload, across all requests.
+	Latency time.Duration
+
+	// Err is the error SendEvents returns, if any.
+	Err error
+}
+
+// AfterSendHook is called with the result of every payload sent, whether it succeeded or not, e.g. to log
+// failures or record latency. ctx is the context set with WithContext.
+type AfterSendHook func(ctx context.Context, result SendResult)
+
+// AddBeforeSend appends hooks to the chain run, in order, before every payload is sent.
+// Hooks should be added before the client is used concurrently.
+func (c *AnalyticsClient) AddBeforeSend(hooks ...BeforeSendHook) {
+	c.BeforeSend = append(c.BeforeSend, hooks...)
+}
+
+// AddAfterSend appends hooks to the chain run, in order, after every payload is sent.
+// Hooks should be added before the client is used concurrently.
+func (c *AnalyticsClient) AddAfterSend(hooks ...AfterSendHook) {
+	c.AfterSend = append(c.AfterSend, hooks...)
+}
+
+// runBeforeSend runs the before-send hooks in order, stopping at the first error.
+func (c *AnalyticsClient) runBeforeSend(ctx context.Context, payload *AnalyticsEvent) error {
+	for _, hook := range c.BeforeSend {
+		if err := hook(ctx, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAfterSend runs the after-send hooks in order.
+func (c *AnalyticsClient) runAfterSend(ctx context.Context, result SendResult) {
+	for _, hook := range c.AfterSend {
+		hook(ctx, result)
+	}
+}
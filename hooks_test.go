@@ -0,0 +1,132 @@
+package ga4m
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantContextKey struct{}
+
+func TestBeforeSend_MutatesPayload(t *testing.T) {
+	client, payloads := recordingClient(t)
+	var order []string
+	client.AddBeforeSend(
+		func(ctx context.Context, payload *AnalyticsEvent) error {
+			order = append(order, "tenant")
+			for i := range payload.Events {
+				payload.Events[i].Params["tenant"] = ctx.Value(tenantContextKey{}).(string)
+			}
+			return nil
+		},
+		func(ctx context.Context, payload *AnalyticsEvent) error {
+			order = append(order, "user")
+			payload.UserID = "user_42"
+			return nil
+		},
+	)
+
+	params := map[string]string{"item_id": "SKU_1"}
+	ctx := context.WithValue(context.Background(), tenantContextKey{}, "acme")
+	require.NoError(t, client.SendEvent(Session{ClientID: "71807069.1731019235"}, "view_item", params, WithContext(ctx)))
+
+	assert.Equal(t, []string{"tenant", "user"}, order)
+	sent := payloads()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "acme", sent[0].Events[0].Params["tenant"])
+	assert.Equal(t, "user_42", sent[0].UserID)
+	assert.NotContains(t, params, "tenant", "the caller's params must not be modified")
+}
+
+func TestBeforeSend_Drop(t *testing.T) {
+	client, payloads := recordingClient(t)
+	afterCalled := false
+	client.AddAfterSend(func(ctx context.Context, result SendResult) { afterCalled = true })
+	client.AddBeforeSend(
+		func(ctx context.Context, payload *AnalyticsEvent) error {
+			if strings.HasPrefix(payload.ClientID, "test.") {
+				return ErrDropPayload
+			}
+			return nil
+		},
+		func(ctx context.Context, payload *AnalyticsEvent) error {
+			t.Error("hooks after a drop must not run")
+			return nil
+		},
+	)
+
+	assert.NoError(t, client.SendEvent(Session{ClientID: "test.1731019235"}, "page_view", nil))
+	assert.Empty(t, payloads())
+	assert.False(t, afterCalled)
+}
+
+func TestBeforeSend_Error(t *testing.T) {
+	client, payloads := recordingClient(t)
+	errTenant := errors.New("unknown tenant")
+	client.AddBeforeSend(func(ctx context.Context, payload *AnalyticsEvent) error { return errTenant })
+
+	err := client.SendEvent(Session{ClientID: "71807069.1731019235"}, "page_view", nil)
+	assert.ErrorIs(t, err, errTenant)
+	assert.Empty(t, payloads())
+}
+
+func TestAfterSend(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		expectedStatus int
+		expectError    bool
+	}{
+		{"Success", http.StatusNoContent, http.StatusNoContent, false},
+		{"Failure", http.StatusBadRequest, http.StatusBadRequest, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []SendResult
+			client, err := New("G-XXXXXXXXXX", "test_secret",
+				WithHTTPClient(&MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(""))}, nil
+				}}),
+				WithBeforeSend(func(ctx context.Context, payload *AnalyticsEvent) error {
+					payload.UserID = "user_42"
+					return nil
+				}),
+				WithAfterSend(func(ctx context.Context, result SendResult) { results = append(results, result) }),
+			)
+			require.NoError(t, err)
+
+			err = client.SendEvent(Session{ClientID: "71807069.1731019235"}, "page_view", nil)
+			assert.Equal(t, tt.expectError, err != nil)
+
+			require.Len(t, results, 1)
+			result := results[0]
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+			assert.Equal(t, err, result.Err)
+			assert.Equal(t, "user_42", result.Payload.UserID)
+			assert.Equal(t, "page_view", result.Payload.Events[0].Name)
+			assert.GreaterOrEqual(t, result.Latency, time.Duration(0))
+		})
+	}
+}
+
+func TestAfterSend_TransportError(t *testing.T) {
+	var result SendResult
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(&MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}})
+	client.AddAfterSend(func(ctx context.Context, r SendResult) { result = r })
+
+	err := client.SendEvent(Session{ClientID: "71807069.1731019235"}, "page_view", nil)
+	require.Error(t, err)
+	assert.Equal(t, 0, result.StatusCode)
+	assert.Equal(t, err, result.Err)
+}
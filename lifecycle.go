@@ -0,0 +1,95 @@
+package ga4m
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultSessionTimeout is the inactivity period after which GA starts a new session
+	DefaultSessionTimeout = 30 * time.Minute
+
+	// DefaultEngagementThreshold is the session duration after which GA considers a session engaged
+	DefaultEngagementThreshold = 10 * time.Second
+
+	// engagedHitCount is the number of hits after which GA considers a session engaged
+	engagedHitCount = 2
+)
+
+// SessionLifecycle applies GA's session rules to sessions tracked entirely server-side,
+// such as API clients and email link clicks that never run gtag.js.
+type SessionLifecycle struct {
+	Timeout             time.Duration // Inactivity timeout, defaults to DefaultSessionTimeout
+	EngagementThreshold time.Duration // Session duration that marks a session engaged, defaults to DefaultEngagementThreshold
+
+	CookieFormat SessionCookieFormat // Format of SessionUpdate.SessionCookieValue, defaults to SessionCookieGS1
+}
+
+// SessionUpdate is the result of recording a hit against a session. GA derives its own session_start and
+// first_visit events, which are reserved and cannot be sent through SendEvents; use SessionStart and FirstVisit
+// to send events under names of your own or to add params to the event being sent.
+type SessionUpdate struct {
+	Session            Session // The updated session
+	SessionStart       bool    // Whether the hit started a new session
+	FirstVisit         bool    // Whether the hit is the client's first visit
+	ClientCookieValue  string  // The updated _ga cookie value
+	SessionCookieValue string  // The updated _ga_* cookie value
+}
+
+// Touch records a hit at now against the session and returns the updated session. A session without a
+// client ID is treated as a new visitor. A new session is started if the session has no session ID or
+// has been inactive for longer than the timeout, incrementing SessionCount and resetting HitCount and
+// engagement. Otherwise HitCount is incremented and the session becomes engaged once it has lasted longer
+// than the engagement threshold or has received a second hit.
+func (l SessionLifecycle) Touch(session Session, now time.Time) SessionUpdate {
+	now = now.Truncate(time.Second)
+	update := SessionUpdate{Session: session}
+	s := &update.Session
+
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSessionTimeout
+	}
+	threshold := l.EngagementThreshold
+	if threshold <= 0 {
+		threshold = DefaultEngagementThreshold
+	}
+
+	switch {
+	case s.ClientID == "":
+		*s = NewSession(now)
+		s.HitCount = 1
+		update.FirstVisit = true
+		update.SessionStart = true
+
+	case s.SessionID == "" || s.LastSession.IsZero() || now.Sub(s.LastSession) >= timeout:
+		s.SessionCount++
+		s.SessionID = strconv.FormatInt(now.Unix(), 10)
+		s.LastSession = now
+		s.IsEngaged = false
+		s.HitCount = 1
+		s.IsFirstSession = s.SessionCount == 1
+		s.IsNewSession = true
+		update.SessionStart = true
+
+	default:
+		s.HitCount++
+		if now.After(s.LastSession) {
+			s.LastSession = now
+		}
+		s.IsNewSession = false
+		if s.HitCount >= engagedHitCount {
+			s.IsEngaged = true
+		}
+		if start, err := strconv.ParseInt(s.SessionID, 10, 64); err == nil && now.Sub(time.Unix(start, 0)) > threshold {
+			s.IsEngaged = true
+		}
+	}
+
+	if s.SessionVersion == "" {
+		s.SessionVersion = "1"
+	}
+	update.ClientCookieValue = s.ClientCookieValue()
+	update.SessionCookieValue = s.SessionCookieValue(l.CookieFormat)
+	return update
+}
@@ -0,0 +1,124 @@
+package ga4m
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionLifecycle_Touch(t *testing.T) {
+	start := time.Unix(1731019235, 0)
+	existing := Session{
+		ClientID:       "476555468.1726969270",
+		ClientVersion:  "1",
+		FirstVisit:     time.Unix(1726969270, 0),
+		SessionCount:   2,
+		LastSession:    start,
+		SessionID:      "1731019235",
+		SessionVersion: "1",
+		HitCount:       1,
+		IsNewSession:   true,
+	}
+
+	tests := []struct {
+		name             string
+		lifecycle        SessionLifecycle
+		session          Session
+		now              time.Time
+		expectedCount    int
+		expectedHits     int
+		expectedEngaged  bool
+		expectedStart    bool
+		expectedFirst    bool
+		expectedNewID    bool
+		expectedFirstSes bool
+	}{
+		{
+			name:             "New Visitor",
+			session:          EmptySession,
+			now:              start,
+			expectedCount:    1,
+			expectedHits:     1,
+			expectedStart:    true,
+			expectedFirst:    true,
+			expectedNewID:    true,
+			expectedFirstSes: true,
+		},
+		{
+			name:            "Continuing Session",
+			session:         existing,
+			now:             start.Add(5 * time.Second),
+			expectedCount:   2,
+			expectedHits:    2,
+			expectedEngaged: true,
+		},
+		{
+			name:            "Long Single Hit Session Is Engaged",
+			session:         Session{ClientID: existing.ClientID, SessionID: existing.SessionID, SessionCount: 2, LastSession: start},
+			now:             start.Add(15 * time.Second),
+			expectedCount:   2,
+			expectedHits:    1,
+			expectedEngaged: true,
+		},
+		{
+			name:          "Timed Out Session",
+			session:       existing,
+			now:           start.Add(31 * time.Minute),
+			expectedCount: 3,
+			expectedHits:  1,
+			expectedStart: true,
+			expectedNewID: true,
+		},
+		{
+			name:          "Custom Timeout",
+			lifecycle:     SessionLifecycle{Timeout: 5 * time.Minute},
+			session:       existing,
+			now:           start.Add(6 * time.Minute),
+			expectedCount: 3,
+			expectedHits:  1,
+			expectedStart: true,
+			expectedNewID: true,
+		},
+		{
+			name:             "Client Without Session",
+			session:          Session{ClientID: existing.ClientID},
+			now:              start,
+			expectedCount:    1,
+			expectedHits:     1,
+			expectedStart:    true,
+			expectedNewID:    true,
+			expectedFirstSes: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := tt.lifecycle.Touch(tt.session, tt.now)
+			s := update.Session
+
+			assert.NotEmpty(t, s.ClientID)
+			assert.Equal(t, tt.expectedCount, s.SessionCount)
+			assert.Equal(t, tt.expectedHits, s.HitCount)
+			assert.Equal(t, tt.expectedEngaged, s.IsEngaged)
+			assert.Equal(t, tt.expectedStart, update.SessionStart)
+			assert.Equal(t, tt.expectedFirst, update.FirstVisit)
+			assert.Equal(t, tt.expectedFirstSes, s.IsFirstSession)
+			assert.Equal(t, tt.expectedStart, s.IsNewSession)
+			assert.Equal(t, tt.now, s.LastSession)
+			if tt.expectedNewID {
+				assert.Equal(t, strconv.FormatInt(tt.now.Unix(), 10), s.SessionID)
+			} else {
+				assert.Equal(t, tt.session.SessionID, s.SessionID)
+			}
+
+			// cookie values round-trip through the parser
+			parsed := parseGoogleAnalyticsCookies(update.ClientCookieValue, update.SessionCookieValue)
+			assert.Equal(t, s.ClientID, parsed.ClientID)
+			assert.Equal(t, s.SessionID, parsed.SessionID)
+			assert.Equal(t, s.SessionCount, parsed.SessionCount)
+			assert.Equal(t, s.HitCount, parsed.HitCount)
+		})
+	}
+}
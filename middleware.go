@@ -1,14 +1,125 @@
 package ga4m
 
-import "github.com/labstack/echo/v4"
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
 
-// GoogleAnalyticsCookieEchoMiddleware extracts user Google Analytics
-// session data from cookies and stores it in the context for later use
-func GoogleAnalyticsCookieEchoMiddleware() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			c.Set(ContextKey, ParseSessionFromEchoContext(c))
-			return next(c)
-		}
+// CookieMiddlewareConfig configures the Google Analytics cookie middleware.
+type CookieMiddlewareConfig struct {
+	// MeasurementID selects the GA4 stream whose session cookie is used, e.g. "G-ABC123".
+	// If empty, the first _ga_* cookie found is used.
+	MeasurementID string
+}
+
+// GoogleAnalyticsCookieMiddleware extracts user Google Analytics session data from
+// cookies and stores it in the request context, see SessionFromContext
+func GoogleAnalyticsCookieMiddleware() func(http.Handler) http.Handler {
+	return GoogleAnalyticsCookieMiddlewareWithConfig(CookieMiddlewareConfig{})
+}
+
+// GoogleAnalyticsCookieMiddlewareWithConfig returns a GoogleAnalyticsCookieMiddleware with config
+func GoogleAnalyticsCookieMiddlewareWithConfig(config CookieMiddlewareConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session := config.ParseSession(r)
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), session)))
+		})
+	}
+}
+
+// ParseSession parses the session for the configured stream from the request.
+func (config CookieMiddlewareConfig) ParseSession(r *http.Request) Session {
+	if config.MeasurementID != "" {
+		return ParseStreamSession(r, config.MeasurementID)
+	}
+	return ParseSessionFromRequest(r)
+}
+
+// PageViewMiddleware sends a page_view (or PageViewConfig.EventName) event after each matching response.
+// The http.ServeMux pattern that handled the request, such as /users/{id}, is used as the page path when the
+// middleware wraps the mux, and the status code and latency are added as parameters. Events are sent in the
+// background so responses are never delayed; set PageViewConfig.Sender to wait for them on shutdown.
+// Integrations for other routers live in their own modules.
+func PageViewMiddleware(config PageViewConfig) func(http.Handler) http.Handler {
+	tracker := NewPageViewTracker(config)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !tracker.Match(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			tracker.Track(r, routePattern(r), rec.Status(), time.Since(start))
+		})
+	}
+}
+
+// routePattern returns the path of the http.ServeMux pattern that matched the request, without the method
+// or host, e.g. "/users/{id}" for "GET example.com/users/{id}".
+func routePattern(r *http.Request) string {
+	pattern := r.Pattern
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		return pattern[i:]
+	}
+	return ""
+}
+
+// statusRecorder records the status code written to a http.ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Status returns the status code written, http.StatusOK if none was written explicitly.
+func (w *statusRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
 	}
+	return w.status
+}
+
+// Flush implements http.Flusher for streaming handlers, flushing the underlying http.ResponseWriter if it
+// supports it.
+func (w *statusRecorder) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, e.g. for WebSocket upgrades. It returns an error wrapping
+// http.ErrNotSupported if the underlying http.ResponseWriter cannot be hijacked.
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
@@ -0,0 +1,96 @@
+package ga4m
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleAnalyticsCookieMiddleware(t *testing.T) {
+	tests := []struct {
+		name              string
+		middleware        func(http.Handler) http.Handler
+		expectedSessionID string
+	}{
+		{"Default", GoogleAnalyticsCookieMiddleware(), "1731000000"},
+		{"Configured Stream", GoogleAnalyticsCookieMiddlewareWithConfig(CookieMiddlewareConfig{MeasurementID: "G-ABC123"}), "1731019235"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+			req.AddCookie(&http.Cookie{Name: sessionCookieName + "OTHER", Value: "GS1.1.1731000000.9.1.1731019762.0.0.0"})
+			req.AddCookie(&http.Cookie{Name: sessionCookieName + "ABC123", Value: "GS1.1.1731019235.2.1.1731019762.0.0.0"})
+
+			var session Session
+			var ok bool
+			handler := tt.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				session, ok = SessionFromContext(r.Context())
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.True(t, ok)
+			assert.Equal(t, "71807069.1731019235", session.ClientID)
+			assert.Equal(t, tt.expectedSessionID, session.SessionID)
+		})
+	}
+}
+
+func TestPageViewMiddleware(t *testing.T) {
+	client, payloads := recordingClient(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /missing/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	sender := NewEventSender(EventSenderConfig{Client: client})
+	handler := GoogleAnalyticsCookieMiddleware()(PageViewMiddleware(PageViewConfig{Sender: sender})(mux))
+
+	for _, r := range []struct{ method, target string }{
+		{http.MethodGet, "/users/42"},
+		{http.MethodGet, "/missing/7"},
+		{http.MethodPost, "/users"},
+	} {
+		req := httptest.NewRequest(r.method, r.target, nil)
+		req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// sends are asynchronous, wait for both tracked requests
+	sender.Wait()
+	assert.Len(t, payloads(), 2)
+
+	statuses := map[string]string{}
+	for _, payload := range payloads() {
+		event := payload.Events[0]
+		assert.Equal(t, PageViewEvent, event.Name)
+		statuses[event.Params["page_path"]] = event.Params[StatusCodeParam]
+		assert.Equal(t, "http://example.com"+event.Params["page_path"], event.Params["page_location"])
+	}
+	assert.Equal(t, map[string]string{"/users/{id}": "200", "/missing/{id}": "404"}, statuses)
+}
+
+func TestStatusRecorder_Flusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &statusRecorder{ResponseWriter: rec}
+
+	flusher, ok := http.ResponseWriter(w).(http.Flusher)
+	require.True(t, ok)
+	flusher.Flush()
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, http.StatusOK, w.Status())
+
+	// httptest.ResponseRecorder cannot be hijacked
+	_, ok = http.ResponseWriter(w).(http.Hijacker)
+	require.True(t, ok)
+	_, _, err := w.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+}
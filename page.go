@@ -0,0 +1,129 @@
+package ga4m
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const (
+	// ForwardedProtoHeader is the header proxies use to pass the original request scheme
+	ForwardedProtoHeader = "X-Forwarded-Proto"
+
+	// ForwardedHostHeader is the header proxies use to pass the original request host
+	ForwardedHostHeader = "X-Forwarded-Host"
+)
+
+// PageContext is the page information GA expects on page_view events, derived from an HTTP request.
+type PageContext struct {
+	Location string // page_location, the full URL of the page
+	Referrer string // page_referrer, from the Referer header
+	Path     string // page_path, the URL path
+	Hostname string // page_hostname, the host without port
+	Language string // language, the preferred language from Accept-Language (e.g., "en-us")
+}
+
+// PageContextFromRequest derives the page context from an HTTP request. X-Forwarded-Proto and X-Forwarded-Host
+// are only honored when the request comes directly from one of the trusted proxies, which must set or append
+// them; their last value is used, as earlier values may have been sent by the client.
+func PageContextFromRequest(r *http.Request, trustedProxies ...netip.Prefix) PageContext {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if isTrustedProxy(r.RemoteAddr, trustedProxies) {
+		if proto := lastHeaderValue(r.Header.Values(ForwardedProtoHeader)); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwardedHost := lastHeaderValue(r.Header.Values(ForwardedHostHeader)); forwardedHost != "" {
+			host = forwardedHost
+		}
+	}
+
+	return PageContext{
+		Location: scheme + "://" + host + r.URL.RequestURI(),
+		Referrer: r.Referer(),
+		Path:     r.URL.Path,
+		Hostname: strings.ToLower(hostWithoutPort(host)),
+		Language: preferredLanguage(r.Header.Get("Accept-Language")),
+	}
+}
+
+// Params returns the page context as event parameters. Empty fields are omitted and values are
+// truncated to GA's maximum length for the parameter, e.g. 1000 bytes for page_location.
+func (p PageContext) Params() map[string]string {
+	params := make(map[string]string)
+	for name, value := range map[string]string{
+		"page_location": p.Location,
+		"page_referrer": p.Referrer,
+		"page_path":     p.Path,
+		"page_hostname": p.Hostname,
+		"language":      p.Language,
+	} {
+		if value != "" {
+			params[name] = truncateString(value, paramValueLength(name))
+		}
+	}
+	return params
+}
+
+// isTrustedProxy reports whether the remote address is within one of the trusted prefixes.
+func isTrustedProxy(remoteAddr string, trustedProxies []netip.Prefix) bool {
+	if len(trustedProxies) == 0 {
+		return false
+	}
+	addr, ok := parseHostAddr(remoteAddr)
+	if !ok {
+		return false
+	}
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHostAddr parses the IP address of a host or host:port.
+func parseHostAddr(hostport string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// firstHeaderValue returns the first entry of a comma-separated header value, e.g. the most preferred language.
+func firstHeaderValue(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+	return strings.TrimSpace(first)
+}
+
+// lastHeaderValue returns the last entry of a header's comma-separated values, the one added by the nearest proxy.
+func lastHeaderValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	value := values[len(values)-1]
+	if i := strings.LastIndexByte(value, ','); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.TrimSpace(value)
+}
+
+// preferredLanguage returns the first language tag of an Accept-Language header, lowercased as gtag.js reports it.
+func preferredLanguage(acceptLanguage string) string {
+	tag, _, _ := strings.Cut(firstHeaderValue(acceptLanguage), ";")
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "*" {
+		return ""
+	}
+	return tag
+}
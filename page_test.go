@@ -0,0 +1,151 @@
+package ga4m
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageContextFromRequest(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		url        string
+		remoteAddr string
+		tls        bool
+		headers    map[string]string
+		expected   PageContext
+	}{
+		{
+			name:       "Plain Request",
+			url:        "http://example.com:8080/products/1?color=red",
+			remoteAddr: "203.0.113.5:1234",
+			headers: map[string]string{
+				"Referer":         "https://www.google.com/",
+				"Accept-Language": "en-US,en;q=0.9",
+			},
+			expected: PageContext{
+				Location: "http://example.com:8080/products/1?color=red",
+				Referrer: "https://www.google.com/",
+				Path:     "/products/1",
+				Hostname: "example.com",
+				Language: "en-us",
+			},
+		},
+		{
+			name:       "TLS Request",
+			url:        "https://Example.com/",
+			remoteAddr: "203.0.113.5:1234",
+			tls:        true,
+			expected: PageContext{
+				Location: "https://Example.com/",
+				Path:     "/",
+				Hostname: "example.com",
+			},
+		},
+		{
+			name:       "Trusted Proxy",
+			url:        "http://internal:8080/checkout",
+			remoteAddr: "10.1.2.3:5555",
+			headers: map[string]string{
+				ForwardedProtoHeader: "https",
+				ForwardedHostHeader:  "shop.example.com",
+			},
+			expected: PageContext{
+				Location: "https://shop.example.com/checkout",
+				Path:     "/checkout",
+				Hostname: "shop.example.com",
+			},
+		},
+		{
+			name:       "Spoofed Values Before Trusted Proxy",
+			url:        "http://internal:8080/checkout",
+			remoteAddr: "10.1.2.3:5555",
+			headers: map[string]string{
+				ForwardedProtoHeader: "http, https",
+				ForwardedHostHeader:  "evil.example.com, shop.example.com",
+			},
+			expected: PageContext{
+				Location: "https://shop.example.com/checkout",
+				Path:     "/checkout",
+				Hostname: "shop.example.com",
+			},
+		},
+		{
+			name:       "Untrusted Proxy",
+			url:        "http://internal:8080/checkout",
+			remoteAddr: "203.0.113.5:1234",
+			headers: map[string]string{
+				ForwardedProtoHeader: "https",
+				ForwardedHostHeader:  "evil.example.com",
+			},
+			expected: PageContext{
+				Location: "http://internal:8080/checkout",
+				Path:     "/checkout",
+				Hostname: "internal",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if !tt.tls {
+				req.TLS = nil
+			} else if req.TLS == nil {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.expected, PageContextFromRequest(req, proxies...))
+		})
+	}
+}
+
+func TestPageContext_Params(t *testing.T) {
+	location := "https://example.com/?utm_source=newsletter&q=" + strings.Repeat("a", 200)
+	page := PageContext{
+		Location: location,
+		Referrer: "https://example.org/" + strings.Repeat("r", 500),
+		Path:     "/" + strings.Repeat("p", 200),
+		Hostname: "example.com",
+	}
+
+	params := page.Params()
+	assert.Len(t, params, 4)
+	assert.Equal(t, location, params["page_location"])
+	assert.Len(t, params["page_referrer"], 420)
+	assert.Len(t, params["page_path"], maxParamValueLength)
+	assert.NoError(t, validateParams(params))
+
+	page.Location = "https://example.com/" + strings.Repeat("a", 2000)
+	assert.Len(t, page.Params()["page_location"], 1000)
+}
+
+func TestSendEvent_WithPageContext(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/pricing", nil)
+	req.Header.Set("Accept-Language", "de-DE")
+
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	payload, _, err := client.BuildPayload(Session{ClientID: "123456.7654321"}, []EventParams{{
+		Name:   "page_view",
+		Params: map[string]string{"page_path": "/pricing/plans"},
+	}}, WithPageContext(req))
+	require.NoError(t, err)
+
+	params := payload.Events[0].Params
+	assert.Equal(t, "http://example.com/pricing", params["page_location"])
+	assert.Equal(t, "/pricing/plans", params["page_path"], "explicit params win over derived ones")
+	assert.Equal(t, "example.com", params["page_hostname"])
+	assert.Equal(t, "de-de", params["language"])
+	assert.NotContains(t, params, "page_referrer")
+}
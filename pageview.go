@@ -0,0 +1,191 @@
+package ga4m
+
+import (
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// PageViewEvent is the name of the event GA records for a page view
+	PageViewEvent = "page_view"
+
+	// StatusCodeParam is the parameter name for the HTTP response status code
+	StatusCodeParam = "status_code"
+
+	// LatencyParam is the parameter name for the server response time in milliseconds
+	LatencyParam = "latency_ms"
+)
+
+// staticAssetExtensions are file extensions of requests that are not page views.
+var staticAssetExtensions = map[string]bool{
+	".css": true, ".js": true, ".mjs": true, ".map": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true, ".avif": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".txt": true, ".xml": true, ".json": true, ".webmanifest": true,
+	".mp4": true, ".webm": true, ".mp3": true, ".pdf": true, ".zip": true,
+}
+
+// healthCheckPaths are paths commonly used by health checks and metrics scrapers.
+var healthCheckPaths = []string{"/health", "/healthz", "/livez", "/readyz", "/ping", "/metrics"}
+
+// RequestMatcher reports whether a request should be tracked.
+type RequestMatcher func(r *http.Request) bool
+
+// MatchMethods matches requests using one of the HTTP methods.
+func MatchMethods(methods ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, method := range methods {
+			if r.Method == method {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SkipStaticAssets matches requests that are not for static assets such as scripts, stylesheets, images and fonts.
+func SkipStaticAssets() RequestMatcher {
+	return func(r *http.Request) bool {
+		return !staticAssetExtensions[strings.ToLower(path.Ext(r.URL.Path))]
+	}
+}
+
+// SkipPathPrefixes matches requests whose path does not start with any of the prefixes.
+func SkipPathPrefixes(prefixes ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SkipHealthChecks matches requests that are not for common health check and metrics paths such as /healthz.
+func SkipHealthChecks() RequestMatcher {
+	return SkipPathPrefixes(healthCheckPaths...)
+}
+
+// DefaultPageViewMatchers are the matchers used when PageViewConfig.Matchers is empty:
+// GET requests that are not for static assets or health checks.
+func DefaultPageViewMatchers() []RequestMatcher {
+	return []RequestMatcher{MatchMethods(http.MethodGet), SkipStaticAssets(), SkipHealthChecks()}
+}
+
+// PageViewConfig configures automatic server-side page_view tracking.
+type PageViewConfig struct {
+	// Client sends the events. Required unless Sender is set.
+	Client *AnalyticsClient
+
+	// EventName is the event sent for each tracked response, defaults to PageViewEvent.
+	EventName string
+
+	// Matchers must all match for a request to be tracked, defaults to DefaultPageViewMatchers.
+	Matchers []RequestMatcher
+
+	// TrustedProxies are the proxies whose X-Forwarded-Proto and X-Forwarded-Host headers are honored.
+	TrustedProxies []netip.Prefix
+
+	// Params, if set, returns extra parameters for the request's event. They win over derived parameters.
+	Params func(r *http.Request) map[string]string
+
+	// Timeout bounds each send, defaults to DefaultSendTimeout.
+	Timeout time.Duration
+
+	// MaxInFlight bounds the number of events being sent at once, defaults to DefaultMaxInFlight.
+	// Events beyond the limit are dropped rather than blocking the response.
+	MaxInFlight int
+
+	// OnError, if set, is called with errors from sending events, including ErrEventDropped.
+	OnError func(error)
+
+	// Sender, if set, sends the events instead of a sender created from Client, Timeout, MaxInFlight and
+	// OnError. Pass a caller-owned sender to call its Wait during graceful shutdown.
+	Sender *EventSender
+}
+
+// PageViewTracker sends an event for each tracked response without blocking the request.
+// PageViewMiddleware and the framework integration modules use it to track responses.
+type PageViewTracker struct {
+	config PageViewConfig
+	sender *EventSender
+}
+
+// NewPageViewTracker creates a new PageViewTracker with the provided config. It panics if neither Sender
+// nor Client is set.
+func NewPageViewTracker(config PageViewConfig) *PageViewTracker {
+	if config.EventName == "" {
+		config.EventName = PageViewEvent
+	}
+	if len(config.Matchers) == 0 {
+		config.Matchers = DefaultPageViewMatchers()
+	}
+	if config.Sender == nil {
+		config.Sender = NewEventSender(EventSenderConfig{
+			Client:      config.Client,
+			Timeout:     config.Timeout,
+			MaxInFlight: config.MaxInFlight,
+			OnError:     config.OnError,
+		})
+	}
+	return &PageViewTracker{config: config, sender: config.Sender}
+}
+
+// Match reports whether the request matches all of the tracker's matchers.
+func (t *PageViewTracker) Match(r *http.Request) bool {
+	for _, match := range t.config.Matchers {
+		if !match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Track sends the event for a completed request in the background. route is the route template that handled
+// the request, such as /users/:id, and is used as the page path to keep cardinality low; if empty the request
+// path is used. The session is taken from the request context, or parsed from the request's cookies.
+// Requests without a client ID are not tracked.
+func (t *PageViewTracker) Track(r *http.Request, route string, status int, latency time.Duration) {
+	session, ok := SessionFromContext(r.Context())
+	if !ok {
+		session = ParseSessionFromRequest(r)
+	}
+	if session.ClientID == "" {
+		return
+	}
+
+	t.sender.Send(r.Context(), session, t.config.EventName, t.params(r, route, status, latency))
+}
+
+// Wait blocks until all events being sent have completed, e.g. during graceful shutdown.
+func (t *PageViewTracker) Wait() {
+	t.sender.Wait()
+}
+
+// params builds the event parameters for a request.
+func (t *PageViewTracker) params(r *http.Request, route string, status int, latency time.Duration) map[string]string {
+	page := PageContextFromRequest(r, t.config.TrustedProxies...)
+	if route != "" {
+		page.Path = route
+		// the route template is used as is, so its braces or colons are not escaped in GA reports
+		if u, err := url.Parse(page.Location); err == nil {
+			page.Location = u.Scheme + "://" + u.Host + route
+		}
+	}
+
+	params := page.Params()
+	params[StatusCodeParam] = strconv.Itoa(status)
+	params[LatencyParam] = strconv.FormatInt(latency.Milliseconds(), 10)
+	if t.config.Params != nil {
+		for k, v := range t.config.Params(r) {
+			params[k] = v
+		}
+	}
+	return params
+}
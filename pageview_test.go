@@ -0,0 +1,133 @@
+package ga4m
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingClient returns a client that records the payloads it sends.
+func recordingClient(t *testing.T) (*AnalyticsClient, func() []AnalyticsEvent) {
+	t.Helper()
+	var mu sync.Mutex
+	var payloads []AnalyticsEvent
+
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(&MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		var payload AnalyticsEvent
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to unmarshal request body: %v", err)
+		}
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}})
+
+	return client, func() []AnalyticsEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]AnalyticsEvent(nil), payloads...)
+	}
+}
+
+func TestDefaultPageViewMatchers(t *testing.T) {
+	tracker := NewPageViewTracker(PageViewConfig{Client: NewClient("G-XXXXXXXXXX", "test_secret")})
+
+	tests := []struct {
+		method   string
+		target   string
+		expected bool
+	}{
+		{http.MethodGet, "/users/42", true},
+		{http.MethodGet, "/", true},
+		{http.MethodPost, "/users", false},
+		{http.MethodHead, "/", false},
+		{http.MethodGet, "/static/app.JS", false},
+		{http.MethodGet, "/favicon.ico", false},
+		{http.MethodGet, "/healthz", false},
+		{http.MethodGet, "/metrics", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			assert.Equal(t, tt.expected, tracker.Match(req))
+		})
+	}
+}
+
+func TestPageViewTracker_Track(t *testing.T) {
+	client, payloads := recordingClient(t)
+	tracker := NewPageViewTracker(PageViewConfig{
+		Client: client,
+		Params: func(r *http.Request) map[string]string {
+			return map[string]string{"app_version": "1.2.3"}
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/users/42?ref=mail", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	tracker.Track(req, "/users/:id", http.StatusOK, 15*time.Millisecond)
+	tracker.Wait()
+
+	sent := payloads()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "71807069.1731019235", sent[0].ClientID)
+	require.Len(t, sent[0].Events, 1)
+	event := sent[0].Events[0]
+	assert.Equal(t, PageViewEvent, event.Name)
+	assert.Equal(t, "/users/:id", event.Params["page_path"])
+	assert.Equal(t, "http://example.com/users/:id", event.Params["page_location"])
+	assert.Equal(t, "200", event.Params[StatusCodeParam])
+	assert.Equal(t, "15", event.Params[LatencyParam])
+	assert.Equal(t, "1.2.3", event.Params["app_version"])
+}
+
+func TestPageViewTracker_TrackWithoutClientID(t *testing.T) {
+	client, payloads := recordingClient(t)
+	tracker := NewPageViewTracker(PageViewConfig{Client: client, EventName: "screen_view"})
+
+	tracker.Track(httptest.NewRequest(http.MethodGet, "/", nil), "/", http.StatusOK, 0)
+	tracker.Wait()
+
+	assert.Empty(t, payloads())
+}
+
+func TestPageViewTracker_DropsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(&MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}})
+
+	var dropped int
+	tracker := NewPageViewTracker(PageViewConfig{
+		Client:      client,
+		MaxInFlight: 1,
+		OnError: func(err error) {
+			if errors.Is(err, ErrEventDropped) {
+				dropped++
+			}
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	tracker.Track(req, "/", http.StatusOK, 0)
+	tracker.Track(req, "/", http.StatusOK, 0) // does not block
+	close(release)
+	tracker.Wait()
+
+	assert.Equal(t, 1, dropped)
+}
@@ -0,0 +1,89 @@
+package ga4m
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// SampleRateParam is the parameter name for the rate an event was sampled at, e.g. "0.1" when one in ten
+// clients send it. Analysts can re-weight counts by its inverse.
+const SampleRateParam = "sample_rate"
+
+// Sampler sends only a fraction of high-volume events. Sampling is deterministic by client ID: a client is
+// either always in or always out for a given rate, and a client in at a lower rate is in at every higher
+// rate, so sampled events can still be combined per user. Events sent at a rate below 1 carry the rate in the
+// sample_rate parameter.
+type Sampler struct {
+	// DefaultRate is the rate for events without an entry in Rates, between 0 and 1. Zero means 1, so only
+	// events listed in Rates are sampled.
+	DefaultRate float64
+
+	// Rates are the rates for individual event names, between 0 and 1, e.g. {"search": 0.1}.
+	Rates map[string]float64
+
+	// NeverSample lists event names that are always sent without a sample_rate, such as purchase,
+	// whatever the rates.
+	NeverSample []string
+
+	// Salt, if set, is hashed with the client ID, so different Samplers pick different clients.
+	Salt string
+}
+
+// NewSampler creates a new Sampler with the given per-event rates. Events without a rate are always sent.
+func NewSampler(rates map[string]float64) *Sampler {
+	return &Sampler{Rates: rates}
+}
+
+// Rate returns the sampling rate for an event name.
+func (s *Sampler) Rate(eventName string) float64 {
+	if slices.Contains(s.NeverSample, eventName) {
+		return 1
+	}
+	if rate, ok := s.Rates[eventName]; ok {
+		return min(max(rate, 0), 1)
+	}
+	if s.DefaultRate <= 0 {
+		return 1
+	}
+	return min(s.DefaultRate, 1)
+}
+
+// Sample reports whether an event should be sent for the client, and the rate it is sampled at.
+func (s *Sampler) Sample(clientID, eventName string) (bool, float64) {
+	rate := s.Rate(eventName)
+	if rate >= 1 {
+		return true, 1
+	}
+	return s.bucket(clientID) < rate, rate
+}
+
+// SampleEvents returns the events that should be sent for the client. Events sent at a rate below 1 are
+// copied with the sample_rate parameter added; the input is never modified.
+func (s *Sampler) SampleEvents(clientID string, events []EventParams) []EventParams {
+	sampled := make([]EventParams, 0, len(events))
+	for _, event := range events {
+		keep, rate := s.Sample(clientID, event.Name)
+		if !keep {
+			continue
+		}
+		if rate < 1 {
+			params := make(map[string]string, len(event.Params)+1)
+			for k, v := range event.Params {
+				params[k] = v
+			}
+			params[SampleRateParam] = strconv.FormatFloat(rate, 'g', -1, 64)
+			event.Params = params
+		}
+		sampled = append(sampled, event)
+	}
+	return sampled
+}
+
+// bucket maps a client ID to a stable number in [0, 1).
+func (s *Sampler) bucket(clientID string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(s.Salt))
+	h.Write([]byte(clientID))
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
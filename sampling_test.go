@@ -0,0 +1,112 @@
+package ga4m
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler_Rate(t *testing.T) {
+	sampler := &Sampler{
+		DefaultRate: 0.5,
+		Rates:       map[string]float64{"search": 0.1, "api_call": 0, "page_view": 2},
+		NeverSample: []string{"purchase"},
+	}
+
+	assert.Equal(t, 0.1, sampler.Rate("search"))
+	assert.Equal(t, 0.0, sampler.Rate("api_call"))
+	assert.Equal(t, 1.0, sampler.Rate("page_view"))
+	assert.Equal(t, 0.5, sampler.Rate("sign_up"))
+	assert.Equal(t, 1.0, sampler.Rate("purchase"))
+
+	// without a default rate, unlisted events are always sent
+	assert.Equal(t, 1.0, NewSampler(map[string]float64{"search": 0.1}).Rate("sign_up"))
+}
+
+func TestSampler_Sample(t *testing.T) {
+	sampler := NewSampler(map[string]float64{"search": 0.1, "api_call": 0.5})
+
+	var searched, called int
+	for i := range 10000 {
+		clientID := fmt.Sprintf("%d.1731019235", i)
+
+		search, rate := sampler.Sample(clientID, "search")
+		assert.Equal(t, 0.1, rate)
+		again, _ := sampler.Sample(clientID, "search")
+		assert.Equal(t, search, again, "sampling must be deterministic")
+
+		call, _ := sampler.Sample(clientID, "api_call")
+		if search {
+			searched++
+			assert.True(t, call, "a client in at a lower rate must be in at a higher one")
+		}
+		if call {
+			called++
+		}
+	}
+
+	assert.InDelta(t, 1000, searched, 150)
+	assert.InDelta(t, 5000, called, 300)
+}
+
+func TestSampler_Salt(t *testing.T) {
+	a := &Sampler{Rates: map[string]float64{"search": 0.5}}
+	b := &Sampler{Rates: map[string]float64{"search": 0.5}, Salt: "experiment_2"}
+
+	differ := false
+	for i := range 100 {
+		clientID := fmt.Sprintf("%d.1731019235", i)
+		inA, _ := a.Sample(clientID, "search")
+		inB, _ := b.Sample(clientID, "search")
+		differ = differ || inA != inB
+	}
+	assert.True(t, differ)
+}
+
+func TestSampler_SampleEvents(t *testing.T) {
+	sampler := &Sampler{Rates: map[string]float64{"search": 0.999999, "api_call": 0}, NeverSample: []string{"purchase"}}
+	params := map[string]string{"search_term": "shoes"}
+	events := []EventParams{
+		{Name: "search", Params: params},
+		{Name: "api_call"},
+		{Name: "purchase", Params: map[string]string{"value": "10"}},
+	}
+
+	sampled := sampler.SampleEvents("71807069.1731019235", events)
+	require.Len(t, sampled, 2)
+	assert.Equal(t, "search", sampled[0].Name)
+	assert.Equal(t, "0.999999", sampled[0].Params[SampleRateParam])
+	assert.Equal(t, "shoes", sampled[0].Params["search_term"])
+	assert.NotContains(t, params, SampleRateParam, "the input must not be modified")
+	assert.Equal(t, "purchase", sampled[1].Name)
+	assert.NotContains(t, sampled[1].Params, SampleRateParam)
+}
+
+func TestSendEvents_Sampler(t *testing.T) {
+	client, payloads := recordingClient(t)
+	client.SetSampler(&Sampler{Rates: map[string]float64{"api_call": 0}})
+	session := Session{ClientID: "71807069.1731019235"}
+
+	// every event sampled out sends nothing
+	require.NoError(t, client.SendEvent(session, "api_call", nil))
+	assert.Empty(t, payloads())
+
+	require.NoError(t, client.SendEvents(session, []EventParams{{Name: "api_call"}, {Name: "purchase"}}))
+	sent := payloads()
+	require.Len(t, sent, 1)
+	require.Len(t, sent[0].Events, 1)
+	assert.Equal(t, "purchase", sent[0].Events[0].Name)
+
+	// a session without a client ID is rejected even when its events are sampled out
+	assert.ErrorContains(t, client.SendEvent(Session{}, "api_call", nil), "must have a valid client ID")
+
+	// invalid events are rejected whether the client is sampled in or out
+	for _, rate := range []float64{0, 1} {
+		client.SetSampler(&Sampler{Rates: map[string]float64{"api call": rate, "_reserved": rate}})
+		assert.ErrorContains(t, client.SendEvent(session, "api call", nil), "invalid event name")
+		assert.ErrorContains(t, client.SendEvents(session, []EventParams{{Name: "purchase"}, {Name: "_reserved"}}), "invalid event name")
+	}
+	assert.Len(t, payloads(), 1)
+}
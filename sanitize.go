@@ -0,0 +1,218 @@
+package ga4m
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SanitizeAction describes the kind of change a Sanitizer made.
+type SanitizeAction string
+
+const (
+	// SanitizeRenamed means an event or parameter name was normalized.
+	SanitizeRenamed SanitizeAction = "renamed"
+
+	// SanitizeTruncated means a parameter value was shortened to the maximum length.
+	SanitizeTruncated SanitizeAction = "truncated"
+
+	// SanitizeDropped means a parameter was removed from the event.
+	SanitizeDropped SanitizeAction = "dropped"
+)
+
+// SanitizeChange describes a single modification made by a Sanitizer.
+type SanitizeChange struct {
+	Event    string         // The event name after sanitizing
+	Param    string         // The original parameter name, empty for event name changes
+	Action   SanitizeAction // What was done
+	Original string         // The original name or value
+	Value    string         // The resulting name or value, empty when dropped
+}
+
+// SanitizeReport collects the changes made while sanitizing an event.
+type SanitizeReport struct {
+	Changes []SanitizeChange
+}
+
+// Changed reports whether the sanitizer modified anything.
+func (r SanitizeReport) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+// Sanitizer repairs event names and parameters that would otherwise be rejected by validation.
+// Names are normalized to snake_case, illegal characters are stripped, values are truncated at
+// character boundaries, and parameters beyond the per-event cap are dropped.
+type Sanitizer struct {
+	// ParamPriority lists parameter names (after normalization) that are kept first when an
+	// event has more parameters than allowed. session_id and engagement_time_msec always come first;
+	// remaining parameters are kept in alphabetical order.
+	ParamPriority []string
+
+	// OnChange, if set, is called for every change the sanitizer makes.
+	OnChange func(SanitizeChange)
+}
+
+// NewSanitizer creates a new Sanitizer with the provided parameter priority list.
+func NewSanitizer(paramPriority ...string) *Sanitizer {
+	return &Sanitizer{ParamPriority: paramPriority}
+}
+
+// SanitizeEvent returns a repaired copy of the event along with a report of what changed.
+// The input event is never modified. An error is returned only if the event name cannot be repaired.
+func (s *Sanitizer) SanitizeEvent(event EventParams) (EventParams, SanitizeReport, error) {
+	var report SanitizeReport
+	record := func(change SanitizeChange) {
+		report.Changes = append(report.Changes, change)
+		if s.OnChange != nil {
+			s.OnChange(change)
+		}
+	}
+
+	name := sanitizeName(event.Name, maxEventNameLength)
+	if name == "" {
+		return event, report, fmt.Errorf("event name '%s' cannot be sanitized", event.Name)
+	}
+	if name != event.Name {
+		record(SanitizeChange{Event: name, Action: SanitizeRenamed, Original: event.Name, Value: name})
+	}
+
+	out := EventParams{
+		Name:            name,
+		TimestampMicros: event.TimestampMicros,
+	}
+	if event.Params == nil {
+		return out, report, nil
+	}
+
+	// process names in sorted order so collisions resolve deterministically,
+	// preferring names that were already valid
+	originals := make([]string, 0, len(event.Params))
+	for k := range event.Params {
+		originals = append(originals, k)
+	}
+	sort.Slice(originals, func(i, j int) bool {
+		iValid := sanitizeName(originals[i], maxParamNameLength) == originals[i]
+		jValid := sanitizeName(originals[j], maxParamNameLength) == originals[j]
+		if iValid != jValid {
+			return iValid
+		}
+		return originals[i] < originals[j]
+	})
+
+	params := make(map[string]string, len(event.Params))
+	for _, original := range originals {
+		value := event.Params[original]
+		key := sanitizeName(original, maxParamNameLength)
+		if key == "" {
+			record(SanitizeChange{Event: name, Param: original, Action: SanitizeDropped, Original: original})
+			continue
+		}
+		if _, exists := params[key]; exists {
+			record(SanitizeChange{Event: name, Param: original, Action: SanitizeDropped, Original: original})
+			continue
+		}
+		if key != original {
+			record(SanitizeChange{Event: name, Param: original, Action: SanitizeRenamed, Original: original, Value: key})
+		}
+		if truncated := truncateString(value, paramValueLength(key)); truncated != value {
+			record(SanitizeChange{Event: name, Param: original, Action: SanitizeTruncated, Original: value, Value: truncated})
+			value = truncated
+		}
+		params[key] = value
+	}
+
+	if len(params) > maxEventParams {
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		rank := s.paramRank()
+		sort.Slice(keys, func(i, j int) bool {
+			ri, iok := rank[keys[i]]
+			rj, jok := rank[keys[j]]
+			switch {
+			case iok && jok:
+				return ri < rj
+			case iok != jok:
+				return iok
+			default:
+				return keys[i] < keys[j]
+			}
+		})
+		for _, k := range keys[maxEventParams:] {
+			record(SanitizeChange{Event: name, Param: k, Action: SanitizeDropped, Original: k})
+			delete(params, k)
+		}
+	}
+
+	out.Params = params
+	return out, report, nil
+}
+
+// paramRank returns the priority position of each parameter name.
+func (s *Sanitizer) paramRank() map[string]int {
+	rank := map[string]int{
+		SessionIDParam:      0,
+		EngagementTimeParam: 1,
+	}
+	for _, name := range s.ParamPriority {
+		name = sanitizeName(name, maxParamNameLength)
+		if _, ok := rank[name]; !ok && name != "" {
+			rank[name] = len(rank)
+		}
+	}
+	return rank
+}
+
+// sanitizeName converts a name to snake_case, strips characters that are not allowed,
+// ensures it starts with a letter and truncates it to max bytes.
+func sanitizeName(name string, max int) string {
+	var b strings.Builder
+	b.Grow(len(name))
+
+	var prev byte // last byte written
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			// split camelCase and the end of acronyms (e.g. "pageURLPath" -> "page_url_path")
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			prevUpper := i > 0 && runes[i-1] >= 'A' && runes[i-1] <= 'Z'
+			if b.Len() > 0 && prev != '_' && (!prevUpper || nextLower) {
+				b.WriteByte('_')
+			}
+			prev = byte(r - 'A' + 'a')
+			b.WriteByte(prev)
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			if b.Len() == 0 && r >= '0' && r <= '9' {
+				continue // names must start with a letter
+			}
+			prev = byte(r)
+			b.WriteByte(prev)
+		case r < utf8.RuneSelf:
+			// ASCII separators and punctuation become underscores
+			if b.Len() > 0 && prev != '_' {
+				prev = '_'
+				b.WriteByte(prev)
+			}
+		}
+	}
+
+	out := b.String()
+	if len(out) > max {
+		out = out[:max]
+	}
+	return strings.TrimRight(out, "_")
+}
+
+// truncateString shortens s to at most max bytes without splitting a multi-byte character.
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
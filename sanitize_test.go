@@ -0,0 +1,175 @@
+package ga4m
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"page_view", "page_view"},
+		{"pageView", "page_view"},
+		{"PageView", "page_view"},
+		{"pageURLPath", "page_url_path"},
+		{"search term", "search_term"},
+		{"item-id.v2", "item_id_v2"},
+		{"__123abc", "abc"},
+		{"café_name", "caf_name"},
+		{"!!!", ""},
+		{strings.Repeat("a", 50), strings.Repeat("a", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeName(tt.input, 40))
+		})
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "short", truncateString("short", 100))
+	assert.Equal(t, strings.Repeat("a", 100), truncateString(strings.Repeat("a", 120), 100))
+
+	// "é" is two bytes, so a cut at byte 100 would split it
+	value := strings.Repeat("a", 99) + "éé"
+	truncated := truncateString(value, 100)
+	assert.Equal(t, strings.Repeat("a", 99), truncated)
+}
+
+func TestSanitizer_SanitizeEvent(t *testing.T) {
+	var changes []SanitizeChange
+	sanitizer := NewSanitizer("search_term")
+	sanitizer.OnChange = func(c SanitizeChange) { changes = append(changes, c) }
+
+	input := EventParams{
+		Name: "Search-Results",
+		Params: map[string]string{
+			"searchTerm": strings.Repeat("x", 150),
+			"page_title": "Results",
+			"!!!":        "dropped",
+		},
+	}
+
+	event, report, err := sanitizer.SanitizeEvent(input)
+	assert.NoError(t, err)
+	assert.Equal(t, "search_results", event.Name)
+	assert.Equal(t, strings.Repeat("x", 100), event.Params["search_term"])
+	assert.Equal(t, "Results", event.Params["page_title"])
+	assert.Len(t, event.Params, 2)
+	assert.True(t, report.Changed())
+	assert.Equal(t, report.Changes, changes)
+
+	// the input is left untouched
+	assert.Equal(t, "Search-Results", input.Name)
+	assert.Len(t, input.Params, 3)
+
+	assert.NoError(t, validateEventName(event.Name))
+	assert.NoError(t, validateParams(event.Params))
+}
+
+func TestSanitizer_SanitizeEvent_Collision(t *testing.T) {
+	event, report, err := NewSanitizer().SanitizeEvent(EventParams{
+		Name: "test_event",
+		Params: map[string]string{
+			"pageTitle":  "camel",
+			"page_title": "snake",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"page_title": "snake"}, event.Params)
+	assert.Len(t, report.Changes, 1)
+	assert.Equal(t, SanitizeDropped, report.Changes[0].Action)
+	assert.Equal(t, "pageTitle", report.Changes[0].Param)
+}
+
+func TestSanitizer_SanitizeEvent_ParamCap(t *testing.T) {
+	params := make(map[string]string)
+	for i := 0; i < 30; i++ {
+		params[fmt.Sprintf("param_%02d", i)] = "value"
+	}
+	params["z_priority"] = "keep"
+	params[SessionIDParam] = "session_123"
+
+	event, report, err := NewSanitizer("z_priority").SanitizeEvent(EventParams{Name: "test_event", Params: params})
+	assert.NoError(t, err)
+	assert.Len(t, event.Params, maxEventParams)
+	assert.Equal(t, "keep", event.Params["z_priority"])
+	assert.Equal(t, "session_123", event.Params[SessionIDParam])
+	assert.Contains(t, event.Params, "param_00")
+	assert.NotContains(t, event.Params, "param_29")
+	assert.Len(t, report.Changes, len(params)-maxEventParams)
+}
+
+func TestSanitizer_SanitizeEvent_InvalidName(t *testing.T) {
+	_, _, err := NewSanitizer().SanitizeEvent(EventParams{Name: "123"})
+	assert.Error(t, err)
+}
+
+func TestSendEvent_Sanitized(t *testing.T) {
+	mockClient := &MockHTTPClient{}
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(mockClient)
+	client.SetSanitizer(NewSanitizer())
+
+	mockClient.DoFunc = func(req *http.Request) (*http.Response, error) {
+		bodyBytes, _ := io.ReadAll(req.Body)
+		var payload AnalyticsEvent
+		if err := json.Unmarshal(bodyBytes, &payload); err != nil {
+			t.Errorf("Failed to unmarshal request body: %v", err)
+		}
+		assert.Equal(t, "search", payload.Events[0].Name)
+		assert.Equal(t, strings.Repeat("q", 100), payload.Events[0].Params["search_term"])
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	err := client.SendEvent(Session{ClientID: "123456.7654321"}, "Search", map[string]string{
+		"search-term": strings.Repeat("q", 200),
+	})
+	assert.NoError(t, err)
+}
+
+func TestBuildPayload_SanitizedParamCap(t *testing.T) {
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetSanitizer(NewSanitizer())
+
+	params := make(map[string]string)
+	for i := 0; i < 30; i++ {
+		params[fmt.Sprintf("param_%02d", i)] = "value"
+	}
+
+	payload, _, err := client.BuildPayload(Session{ClientID: "123456.7654321", SessionID: "session_123"}, []EventParams{{Name: "test_event", Params: params}})
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(payload.Events[0].Params), maxEventParams)
+	assert.Equal(t, "session_123", payload.Events[0].Params[SessionIDParam])
+	assert.Equal(t, DefaultEngagementTimeMS, payload.Events[0].Params[EngagementTimeParam])
+}
+
+func TestBuildPayload_StrictParamCap(t *testing.T) {
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	session := Session{ClientID: "123456.7654321", SessionID: "session_123"}
+
+	// without a sanitizer, the session params do not count towards the caller's 25 params
+	params := make(map[string]string)
+	for i := 0; i < maxEventParams; i++ {
+		params[fmt.Sprintf("param_%02d", i)] = "value"
+	}
+	payload, _, err := client.BuildPayload(session, []EventParams{{Name: "test_event", Params: params}})
+	assert.NoError(t, err)
+	assert.Equal(t, "session_123", payload.Events[0].Params[SessionIDParam])
+
+	params["param_25"] = "value"
+	_, _, err = client.BuildPayload(session, []EventParams{{Name: "test_event", Params: params}})
+	assert.ErrorContains(t, err, "maximum of 25 parameters")
+}
@@ -0,0 +1,212 @@
+package ga4m
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaMode controls how AnalyticsClient handles events that violate its schema.
+type SchemaMode int
+
+const (
+	// SchemaStrict rejects events that violate the schema.
+	SchemaStrict SchemaMode = iota
+
+	// SchemaWarn reports violations to the client's OnSchemaViolation handler and sends the event anyway.
+	SchemaWarn
+)
+
+// ParamType is the value type of an event parameter declared in a Schema.
+type ParamType string
+
+const (
+	ParamTypeString ParamType = "string"
+	ParamTypeInt    ParamType = "int"
+	ParamTypeNumber ParamType = "number"
+	ParamTypeBool   ParamType = "bool"
+)
+
+// ParamSchema declares an event parameter.
+type ParamSchema struct {
+	Type      ParamType `json:"type,omitempty" yaml:"type,omitempty"`             // Value type, defaults to string
+	Required  bool      `json:"required,omitempty" yaml:"required,omitempty"`     // Whether the parameter must be present
+	Enum      []string  `json:"enum,omitempty" yaml:"enum,omitempty"`             // Allowed values, if restricted
+	MaxLength int       `json:"max_length,omitempty" yaml:"max_length,omitempty"` // Maximum value length in bytes, if restricted
+}
+
+// EventSchema declares an event and the parameters it accepts.
+type EventSchema struct {
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Params      map[string]ParamSchema `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// Schema is a catalog of the events an application is allowed to send.
+// session_id and engagement_time_msec, and the traffic_type and sample_rate parameters added by BotTag and a
+// Sampler, are always allowed and need not be declared.
+type Schema struct {
+	Events map[string]EventSchema `json:"events" yaml:"events"`
+}
+
+// SchemaViolation describes an event that does not conform to a Schema.
+type SchemaViolation struct {
+	Event  string // The event name
+	Param  string // The parameter name, empty for event-level violations
+	Reason string // Why the event or parameter is invalid
+}
+
+// Error implements the error interface.
+func (v *SchemaViolation) Error() string {
+	if v.Param == "" {
+		return fmt.Sprintf("event '%s': %s", v.Event, v.Reason)
+	}
+	return fmt.Sprintf("event '%s' parameter '%s': %s", v.Event, v.Param, v.Reason)
+}
+
+// LoadSchema reads a schema from a JSON (.json) file. YAML schemas are loaded with the
+// github.com/agentstation/ga4m/yaml module, which keeps the core module free of a YAML dependency.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return ParseSchemaJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported schema file extension '%s'", ext)
+	}
+}
+
+// ParseSchemaJSON parses and checks a schema from JSON.
+func ParseSchemaJSON(data []byte) (*Schema, error) {
+	var schema Schema
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if err := schema.Check(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// Check verifies the schema itself is usable. The Parse functions call it; schemas decoded or built
+// otherwise should be checked before use.
+func (s *Schema) Check() error {
+	if len(s.Events) == 0 {
+		return fmt.Errorf("schema must declare at least one event")
+	}
+	for name, event := range s.Events {
+		if err := validateEventName(name); err != nil {
+			return fmt.Errorf("schema event '%s': %w", name, err)
+		}
+		for param, ps := range event.Params {
+			if err := validateParams(map[string]string{param: ""}); err != nil {
+				return fmt.Errorf("schema event '%s': %w", name, err)
+			}
+			switch ps.Type {
+			case "", ParamTypeString, ParamTypeInt, ParamTypeNumber, ParamTypeBool:
+			default:
+				return fmt.Errorf("schema event '%s' parameter '%s': unknown type '%s'", name, param, ps.Type)
+			}
+			for _, value := range ps.Enum {
+				if err := checkParamType(ps.Type, value); err != nil {
+					return fmt.Errorf("schema event '%s' parameter '%s': enum value '%s' %w", name, param, value, err)
+				}
+			}
+			if ps.MaxLength < 0 {
+				return fmt.Errorf("schema event '%s' parameter '%s': max_length cannot be negative", name, param)
+			}
+		}
+	}
+	return nil
+}
+
+// implicitParams are the parameters added by the client that a schema need not declare.
+var implicitParams = map[string]bool{
+	SessionIDParam:      true,
+	EngagementTimeParam: true,
+	TrafficTypeParam:    true,
+	SampleRateParam:     true,
+}
+
+// ValidateEvent checks an event against the schema. It returns nil if the event conforms,
+// otherwise an error joining a *SchemaViolation for every problem found.
+func (s *Schema) ValidateEvent(event EventParams) error {
+	es, ok := s.Events[event.Name]
+	if !ok {
+		return &SchemaViolation{Event: event.Name, Reason: "event is not declared in the schema"}
+	}
+
+	var errs []error
+	violation := func(param, format string, args ...any) {
+		errs = append(errs, &SchemaViolation{Event: event.Name, Param: param, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	// check declared params in a stable order
+	names := make([]string, 0, len(es.Params))
+	for name := range es.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ps := es.Params[name]
+		value, present := event.Params[name]
+		if !present {
+			if ps.Required {
+				violation(name, "required parameter is missing")
+			}
+			continue
+		}
+		if err := checkParamType(ps.Type, value); err != nil {
+			violation(name, "value '%s' %s", value, err)
+		}
+		if len(ps.Enum) > 0 && !slices.Contains(ps.Enum, value) {
+			violation(name, "value '%s' is not one of %v", value, ps.Enum)
+		}
+		if ps.MaxLength > 0 && len(value) > ps.MaxLength {
+			violation(name, "value exceeds maximum length of %d", ps.MaxLength)
+		}
+	}
+
+	// reject params the schema does not know about
+	var undeclared []string
+	for name := range event.Params {
+		if _, ok := es.Params[name]; !ok && !implicitParams[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		violation(name, "parameter is not declared in the schema")
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkParamType reports whether value can be interpreted as type t.
+func checkParamType(t ParamType, value string) error {
+	var err error
+	switch t {
+	case ParamTypeInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case ParamTypeNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case ParamTypeBool:
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return fmt.Errorf("is not a valid %s", t)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package ga4m
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchemaJSON = `{
+  "events": {
+    "search": {
+      "description": "A site search",
+      "params": {
+        "search_term": {"type": "string", "required": true, "max_length": 50},
+        "results": {"type": "int"}
+      }
+    },
+    "purchase": {
+      "params": {
+        "currency": {"required": true, "enum": ["USD", "EUR"]},
+        "value": {"type": "number", "required": true},
+        "coupon_applied": {"type": "bool"}
+      }
+    }
+  }
+}`
+
+func TestParseSchemaJSON(t *testing.T) {
+	schema, err := ParseSchemaJSON([]byte(testSchemaJSON))
+	require.NoError(t, err)
+	assert.Len(t, schema.Events, 2)
+	assert.Equal(t, "A site search", schema.Events["search"].Description)
+	assert.Equal(t, ParamSchema{Type: ParamTypeString, Required: true, MaxLength: 50}, schema.Events["search"].Params["search_term"])
+	assert.Equal(t, ParamTypeInt, schema.Events["search"].Params["results"].Type)
+	assert.Equal(t, []string{"USD", "EUR"}, schema.Events["purchase"].Params["currency"].Enum)
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected string
+	}{
+		{"No Events", `{"events": {}}`, "at least one event"},
+		{"Invalid Event Name", `{"events": {"1bad": {}}}`, "must start with a letter"},
+		{"Invalid Param Name", `{"events": {"ok": {"params": {"bad-name": {}}}}}`, "alphanumeric"},
+		{"Unknown Type", `{"events": {"ok": {"params": {"p": {"type": "date"}}}}}`, "unknown type"},
+		{"Enum Type Mismatch", `{"events": {"ok": {"params": {"p": {"type": "int", "enum": ["a"]}}}}}`, "is not a valid int"},
+		{"Unknown Field", `{"events": {"ok": {"parameters": {}}}}`, "failed to parse schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchemaJSON([]byte(tt.json))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "events.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(testSchemaJSON), 0o600))
+	schema, err := LoadSchema(jsonPath)
+	require.NoError(t, err)
+	assert.Len(t, schema.Events, 2)
+
+	yamlPath := filepath.Join(dir, "events.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("events: {}"), 0o600))
+	_, err = LoadSchema(yamlPath)
+	assert.ErrorContains(t, err, "unsupported schema file extension")
+
+	_, err = LoadSchema(filepath.Join(dir, "events.toml"))
+	assert.Error(t, err)
+}
+
+func TestSchema_ValidateEvent(t *testing.T) {
+	schema, err := ParseSchemaJSON([]byte(testSchemaJSON))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		event      EventParams
+		violations []string
+	}{
+		{
+			name:  "Valid Event",
+			event: EventParams{Name: "search", Params: map[string]string{"search_term": "shoes", "results": "12", SessionIDParam: "123"}},
+		},
+		{
+			name:  "Implicit Params",
+			event: EventParams{Name: "search", Params: map[string]string{"search_term": "shoes", TrafficTypeParam: BotTrafficType, SampleRateParam: "0.1"}},
+		},
+		{
+			name:       "Undeclared Event",
+			event:      EventParams{Name: "signup"},
+			violations: []string{"event is not declared"},
+		},
+		{
+			name:       "Missing Required Params",
+			event:      EventParams{Name: "purchase"},
+			violations: []string{"'currency': required", "'value': required"},
+		},
+		{
+			name:       "Wrong Types",
+			event:      EventParams{Name: "purchase", Params: map[string]string{"currency": "GBP", "value": "ten", "coupon_applied": "maybe"}},
+			violations: []string{"is not one of", "is not a valid number", "is not a valid bool"},
+		},
+		{
+			name:       "Too Long And Undeclared",
+			event:      EventParams{Name: "search", Params: map[string]string{"search_term": strings.Repeat("x", 51), "extra": "1"}},
+			violations: []string{"exceeds maximum length of 50", "'extra': parameter is not declared"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateEvent(tt.event)
+			if len(tt.violations) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			var violation *SchemaViolation
+			assert.True(t, errors.As(err, &violation))
+			for _, expected := range tt.violations {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestSendEvent_SchemaModes(t *testing.T) {
+	schema, err := ParseSchemaJSON([]byte(testSchemaJSON))
+	require.NoError(t, err)
+
+	var requests int
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+	session := Session{ClientID: "123456.7654321"}
+
+	// strict mode rejects the event before sending
+	strict, err := New("G-ABC123", "test_secret", WithHTTPClient(mockClient), WithSchema(schema, SchemaStrict))
+	require.NoError(t, err)
+	err = strict.SendEvent(session, "signup", nil)
+	assert.ErrorContains(t, err, "schema violation")
+	assert.NoError(t, strict.SendEvent(session, "search", map[string]string{"search_term": "shoes"}))
+	assert.Equal(t, 1, requests)
+
+	// warn mode reports the violation and sends anyway
+	var reported []string
+	warn, err := New("G-ABC123", "test_secret",
+		WithHTTPClient(mockClient),
+		WithSchema(schema, SchemaWarn),
+		WithSchemaViolationHandler(func(event EventParams, err error) {
+			reported = append(reported, event.Name)
+		}),
+	)
+	require.NoError(t, err)
+	assert.NoError(t, warn.SendEvent(session, "signup", nil))
+	assert.Equal(t, []string{"signup"}, reported)
+	assert.Equal(t, 2, requests)
+}
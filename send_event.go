@@ -3,9 +3,11 @@ package ga4m
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 const (
@@ -21,6 +23,9 @@ const (
 	// MaxEventsPerRequest is the maximum number of events per request
 	MaxEventsPerRequest = 25
 
+	// MaxPayloadBytes is the maximum size of a request body accepted by the Measurement Protocol
+	MaxPayloadBytes = 130 * 1024
+
 	// URLFormat is the format for the URL
 	URLFormat = "%s?measurement_id=%s&api_secret=%s"
 
@@ -31,6 +36,12 @@ const (
 	ContentTypeJSON = "application/json"
 )
 
+// ErrEventTooLarge is returned when a single event cannot fit within MaxPayloadBytes.
+var ErrEventTooLarge = errors.New("event exceeds maximum payload size")
+
+// errNoClientID is returned for a session without a client ID.
+var errNoClientID = errors.New("session must have a valid client ID")
+
 // EventParams represents parameters for a GA4 event.
 type EventParams struct {
 	Name            string            `json:"name"`
@@ -44,153 +55,258 @@ type AnalyticsEvent struct {
 	Events          []EventParams `json:"events"`
 	UserID          string        `json:"user_id,omitempty"`
 	TimestampMicros int64         `json:"timestamp_micros,omitempty"`
+	Device          *Device       `json:"device,omitempty"`
 }
 
 // SendEvent sends a single event to Google Analytics.
 func (c *AnalyticsClient) SendEvent(session Session, eventName string, params map[string]string, opts ...SendEventOption) error {
-	if session.ClientID == "" {
-		return fmt.Errorf("session must have a valid client ID")
-	}
-
-	if err := validateEventName(eventName); err != nil {
-		return fmt.Errorf("invalid event name: %w", err)
-	}
-
-	if err := validateParams(params); err != nil {
-		return fmt.Errorf("invalid parameters: %w", err)
-	}
+	return c.SendEvents(session, []EventParams{{Name: eventName, Params: params}}, opts...)
+}
 
-	// Apply default options.
-	options := defaultSendEventOptions()
-	for _, opt := range opts {
-		opt(options)
+// SendEvents sends multiple events in a single batch request to Google Analytics.
+// The events and their params are never modified.
+// Events sent with a context classified as bot traffic are dropped or tagged according to the BotPolicy,
+// and events not chosen by the Sampler are dropped. Every event is validated, including those sampled out.
+func (c *AnalyticsClient) SendEvents(session Session, events []EventParams, opts ...SendEventOption) error {
+	options := applySendEventOptions(opts)
+	if verdict, ok := BotVerdictFromContext(options.ctx); ok && verdict.Bot {
+		switch c.BotPolicy {
+		case BotDrop:
+			return nil
+		case BotTag:
+			options.addDefaultParams(map[string]string{TrafficTypeParam: BotTrafficType})
+		}
 	}
 
-	// Use session ID from session if not explicitly provided in options
-	if options.sessionID == "" && session.SessionID != "" {
-		options.sessionID = session.SessionID
+	payload, err := c.buildPayload(session, events, options)
+	if err != nil {
+		return err
 	}
 
-	// Initialize params if nil.
-	if params == nil {
-		params = make(map[string]string)
-	} else {
-		// Create a copy of the params map to avoid modifying the original
-		paramsCopy := make(map[string]string, len(params))
-		for k, v := range params {
-			paramsCopy[k] = v
+	// Sample the built events so invalid events are reported whichever bucket the client falls in
+	if c.Sampler != nil {
+		if payload.Events = c.Sampler.SampleEvents(session.ClientID, payload.Events); len(payload.Events) == 0 {
+			return nil
 		}
-		params = paramsCopy
 	}
 
-	// Add required session parameters if not present.
-	if options.sessionID != "" {
-		if _, ok := params[SessionIDParam]; !ok {
-			params[SessionIDParam] = options.sessionID
+	if err := c.runBeforeSend(options.ctx, &payload); err != nil {
+		if errors.Is(err, ErrDropPayload) {
+			return nil
 		}
-	}
-	if _, ok := params[EngagementTimeParam]; !ok {
-		params[EngagementTimeParam] = DefaultEngagementTimeMS
-	}
-
-	event := EventParams{
-		Name:   eventName,
-		Params: params,
+		return fmt.Errorf("before send hook: %w", err)
 	}
 
-	if !options.timestamp.IsZero() {
-		event.TimestampMicros = options.timestamp.UnixMicro()
-	}
-
-	payload := AnalyticsEvent{
-		ClientID: session.ClientID,
-		Events:   []EventParams{event},
-	}
+	start := time.Now()
+	status, err := c.sendPayload(payload, options)
+	c.runAfterSend(options.ctx, SendResult{Payload: payload, StatusCode: status, Latency: time.Since(start), Err: err})
+	return err
+}
 
-	if options.userID != "" {
-		payload.UserID = options.userID
+// BuildPayload builds the payload SendEvents would send for the session and events,
+// returning it along with its JSON encoding without sending anything. SendEvents may
+// split the payload across several requests if the encoding exceeds MaxPayloadBytes.
+// The BotPolicy, Sampler and BeforeSend hooks are not applied.
+func (c *AnalyticsClient) BuildPayload(session Session, events []EventParams, opts ...SendEventOption) (AnalyticsEvent, []byte, error) {
+	payload, err := c.buildPayload(session, events, applySendEventOptions(opts))
+	if err != nil {
+		return AnalyticsEvent{}, nil, err
 	}
 
-	if !options.timestamp.IsZero() {
-		payload.TimestampMicros = options.timestamp.UnixMicro()
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return AnalyticsEvent{}, nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	return c.sendPayload(payload, options)
+	return payload, payloadBytes, nil
 }
 
-// SendEvents sends multiple events in a single batch request to Google Analytics.
-func (c *AnalyticsClient) SendEvents(session Session, events []EventParams, opts ...SendEventOption) error {
+// buildPayload validates the events and builds the payload from copies of them,
+// adding the required session parameters to each event.
+func (c *AnalyticsClient) buildPayload(session Session, events []EventParams, options *sendEventOptions) (AnalyticsEvent, error) {
 	if len(events) > MaxEventsPerRequest {
-		return fmt.Errorf("requests can have a maximum of %d events", MaxEventsPerRequest)
+		return AnalyticsEvent{}, fmt.Errorf("requests can have a maximum of %d events", MaxEventsPerRequest)
 	}
 
 	// Validate client ID from session
 	if session.ClientID == "" {
-		return fmt.Errorf("session must have a valid client ID")
-	}
-
-	for _, event := range events {
-		if err := validateEventName(event.Name); err != nil {
-			return fmt.Errorf("invalid event name '%s': %w", event.Name, err)
-		}
-		if err := validateParams(event.Params); err != nil {
-			return fmt.Errorf("invalid parameters for event '%s': %w", event.Name, err)
-		}
-	}
-
-	// Apply default options
-	options := defaultSendEventOptions()
-	for _, opt := range opts {
-		opt(options)
+		return AnalyticsEvent{}, errNoClientID
 	}
 
 	// Use session ID from session if not explicitly provided in options
-	if options.sessionID == "" && session.SessionID != "" {
-		options.sessionID = session.SessionID
+	sessionID := options.sessionID
+	if sessionID == "" {
+		sessionID = session.SessionID
 	}
 
-	// Add required session parameters to each event if not present
-	for i := range events {
-		if events[i].Params == nil {
-			events[i].Params = make(map[string]string)
+	built := make([]EventParams, 0, len(events))
+	for _, event := range events {
+		// Copy the params so the caller's map is never modified, merging the default params before
+		// validation so they count towards the limits
+		params := make(map[string]string, len(options.defaultParams)+len(event.Params)+2)
+		for k, v := range options.defaultParams {
+			params[k] = v
+		}
+		for k, v := range event.Params {
+			params[k] = v
 		}
-		if options.sessionID != "" {
-			if _, ok := events[i].Params[SessionIDParam]; !ok {
-				events[i].Params[SessionIDParam] = options.sessionID
+		event.Params = params
+
+		if c.Sanitizer != nil {
+			// the sanitizer keeps the session params within the parameter cap, so add them first
+			addSessionParams(event.Params, sessionID)
+			sanitized, _, err := c.Sanitizer.SanitizeEvent(event)
+			if err != nil {
+				return AnalyticsEvent{}, fmt.Errorf("invalid event name '%s': %w", event.Name, err)
+			}
+			event = sanitized
+		} else {
+			if err := validateEventName(event.Name); err != nil {
+				return AnalyticsEvent{}, fmt.Errorf("invalid event name '%s': %w", event.Name, err)
 			}
+			if err := validateParams(event.Params); err != nil {
+				return AnalyticsEvent{}, fmt.Errorf("invalid parameters for event '%s': %w", event.Name, err)
+			}
+			addSessionParams(event.Params, sessionID)
 		}
-		if _, ok := events[i].Params[EngagementTimeParam]; !ok {
-			events[i].Params[EngagementTimeParam] = DefaultEngagementTimeMS
+
+		if c.Schema != nil {
+			if err := c.Schema.ValidateEvent(event); err != nil {
+				if c.SchemaMode == SchemaStrict {
+					return AnalyticsEvent{}, fmt.Errorf("schema violation: %w", err)
+				}
+				if c.OnSchemaViolation != nil {
+					c.OnSchemaViolation(event, err)
+				}
+			}
 		}
 
-		if !options.timestamp.IsZero() && events[i].TimestampMicros == 0 {
-			events[i].TimestampMicros = options.timestamp.UnixMicro()
+		out := EventParams{
+			Name:            event.Name,
+			Params:          event.Params,
+			TimestampMicros: event.TimestampMicros,
 		}
+		if !options.timestamp.IsZero() && out.TimestampMicros == 0 {
+			out.TimestampMicros = options.timestamp.UnixMicro()
+		}
+		built = append(built, out)
 	}
 
 	payload := AnalyticsEvent{
 		ClientID: session.ClientID,
-		Events:   events,
-	}
-
-	if options.userID != "" {
-		payload.UserID = options.userID
+		Events:   built,
+		UserID:   options.userID,
+		Device:   options.device,
 	}
 
 	if !options.timestamp.IsZero() {
 		payload.TimestampMicros = options.timestamp.UnixMicro()
 	}
 
-	return c.sendPayload(payload, options)
+	return payload, nil
+}
+
+// addSessionParams adds the required session parameters to params if not present.
+func addSessionParams(params map[string]string, sessionID string) {
+	if sessionID != "" {
+		if _, ok := params[SessionIDParam]; !ok {
+			params[SessionIDParam] = sessionID
+		}
+	}
+	if _, ok := params[EngagementTimeParam]; !ok {
+		params[EngagementTimeParam] = DefaultEngagementTimeMS
+	}
+}
+
+// sendPayload sends the payload to the Google Analytics endpoint, splitting it into
+// multiple requests if the marshalled payload exceeds MaxPayloadBytes. It returns the
+// status of the last response received.
+func (c *AnalyticsClient) sendPayload(payload AnalyticsEvent, options *sendEventOptions) (int, error) {
+	batches, err := splitPayload(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var status int
+	for i, batch := range batches {
+		if status, err = c.postPayload(batch, options); err != nil {
+			if len(batches) > 1 {
+				return status, fmt.Errorf("batch %d of %d: %w", i+1, len(batches), err)
+			}
+			return status, err
+		}
+	}
+	return status, nil
 }
 
-// sendPayload handles the HTTP request to the Google Analytics endpoint.
-func (c *AnalyticsClient) sendPayload(payload AnalyticsEvent, options *sendEventOptions) error {
+// splitPayload marshals the payload, splitting its events across as many payloads as needed
+// so that each marshalled payload fits within MaxPayloadBytes.
+func splitPayload(payload AnalyticsEvent) ([][]byte, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if len(payloadBytes) <= MaxPayloadBytes {
+		return [][]byte{payloadBytes}, nil
+	}
+
+	// measure the envelope without events, then pack events greedily
+	envelope := payload
+	envelope.Events = []EventParams{}
+	envelopeBytes, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	baseSize := len(envelopeBytes)
+
+	var batches [][]byte
+	var current []EventParams
+	size := baseSize
+	flush := func() error {
+		if len(current) == 0 {
+			return nil
+		}
+		batch := payload
+		batch.Events = current
+		batchBytes, err := json.Marshal(batch)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		batches = append(batches, batchBytes)
+		current, size = nil, baseSize
+		return nil
 	}
 
+	for _, event := range payload.Events {
+		eventBytes, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event '%s': %w", event.Name, err)
+		}
+		eventSize := len(eventBytes)
+		if baseSize+eventSize > MaxPayloadBytes {
+			return nil, fmt.Errorf("event '%s' is %d bytes: %w", event.Name, eventSize, ErrEventTooLarge)
+		}
+		if len(current) > 0 {
+			eventSize++ // separating comma
+		}
+		if size+eventSize > MaxPayloadBytes {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			eventSize = len(eventBytes)
+		}
+		current = append(current, event)
+		size += eventSize
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+// postPayload handles the HTTP request to the Google Analytics endpoint, returning the response status.
+func (c *AnalyticsClient) postPayload(payloadBytes []byte, options *sendEventOptions) (int, error) {
 	endpoint := c.Endpoint
 	if options.debug {
 		endpoint = c.DebugEndpoint
@@ -200,20 +316,20 @@ func (c *AnalyticsClient) sendPayload(payload AnalyticsEvent, options *sendEvent
 
 	req, err := http.NewRequestWithContext(options.ctx, "POST", url, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set(ContentTypeHeader, ContentTypeJSON)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return 0, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("received non-OK status: %d, body: %s", resp.StatusCode, string(body))
+		return resp.StatusCode, fmt.Errorf("received non-OK status: %d, body: %s", resp.StatusCode, string(body))
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
@@ -2,6 +2,8 @@ package ga4m
 
 import (
 	"context"
+	"net/http"
+	"net/netip"
 	"time"
 )
 
@@ -14,6 +16,10 @@ type sendEventOptions struct {
 	userID    string
 	timestamp time.Time
 	sessionID string
+	device    *Device
+
+	// defaultParams are added to every event that does not already set them
+	defaultParams map[string]string
 }
 
 func defaultSendEventOptions() *sendEventOptions {
@@ -23,6 +29,15 @@ func defaultSendEventOptions() *sendEventOptions {
 	}
 }
 
+// applySendEventOptions returns the default options with opts applied.
+func applySendEventOptions(opts []SendEventOption) *sendEventOptions {
+	options := defaultSendEventOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
 // WithContext sets a custom context for the request.
 func WithContext(ctx context.Context) SendEventOption {
 	return func(o *sendEventOptions) {
@@ -57,3 +72,32 @@ func WithSessionID(sessionID string) SendEventOption {
 		o.sessionID = sessionID
 	}
 }
+
+// WithDevice sets the device information for the request, e.g. from ParseDevice.
+func WithDevice(device Device) SendEventOption {
+	return func(o *sendEventOptions) {
+		o.device = &device
+	}
+}
+
+// WithPageContext adds the page_location, page_referrer, page_path, page_hostname and language
+// parameters derived from the request to every event. Parameters set explicitly on an event win.
+// X-Forwarded-Proto and X-Forwarded-Host are only honored from the trusted proxies.
+func WithPageContext(r *http.Request, trustedProxies ...netip.Prefix) SendEventOption {
+	params := PageContextFromRequest(r, trustedProxies...).Params()
+	return func(o *sendEventOptions) {
+		o.addDefaultParams(params)
+	}
+}
+
+// addDefaultParams merges params into the default params, keeping values already set.
+func (o *sendEventOptions) addDefaultParams(params map[string]string) {
+	if o.defaultParams == nil {
+		o.defaultParams = make(map[string]string, len(params))
+	}
+	for k, v := range params {
+		if _, ok := o.defaultParams[k]; !ok {
+			o.defaultParams[k] = v
+		}
+	}
+}
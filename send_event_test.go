@@ -2,10 +2,12 @@ package ga4m
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -317,3 +319,207 @@ func TestSendEvent_EmptySession(t *testing.T) {
 		t.Errorf("Expected client ID validation error, got: %v", err)
 	}
 }
+
+func TestSendEvents_SplitsLargePayload(t *testing.T) {
+	session := Session{
+		ClientID:  "123456.7654321",
+		SessionID: "session_123",
+	}
+
+	// '<' is escaped to \u003c by encoding/json, so each value marshals to 600 bytes
+	events := make([]EventParams, MaxEventsPerRequest)
+	for i := range events {
+		params := make(map[string]string)
+		for j := 0; j < 20; j++ {
+			params[fmt.Sprintf("param_%d", j)] = strings.Repeat("<", maxParamValueLength)
+		}
+		events[i] = EventParams{Name: fmt.Sprintf("event_%d", i), Params: params}
+	}
+
+	mockClient := &MockHTTPClient{}
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(mockClient)
+
+	var requests, received int
+	mockClient.DoFunc = func(req *http.Request) (*http.Response, error) {
+		bodyBytes, _ := io.ReadAll(req.Body)
+		if len(bodyBytes) > MaxPayloadBytes {
+			t.Errorf("Expected payload of at most %d bytes, got %d", MaxPayloadBytes, len(bodyBytes))
+		}
+		var payload AnalyticsEvent
+		if err := json.Unmarshal(bodyBytes, &payload); err != nil {
+			t.Errorf("Failed to unmarshal request body: %v", err)
+		}
+		if payload.ClientID != session.ClientID {
+			t.Errorf("Expected ClientID %s, got %s", session.ClientID, payload.ClientID)
+		}
+		requests++
+		received += len(payload.Events)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	if err := client.SendEvents(session, events); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests < 2 {
+		t.Errorf("Expected payload to be split into multiple requests, got %d", requests)
+	}
+	if received != len(events) {
+		t.Errorf("Expected %d events to be sent, got %d", len(events), received)
+	}
+}
+
+func TestSplitPayload_EventTooLarge(t *testing.T) {
+	payload := AnalyticsEvent{
+		ClientID: "123456.7654321",
+		Events: []EventParams{{
+			Name:   "huge_event",
+			Params: map[string]string{"blob": strings.Repeat("x", MaxPayloadBytes)},
+		}},
+	}
+
+	_, err := splitPayload(payload)
+	if !errors.Is(err, ErrEventTooLarge) {
+		t.Errorf("Expected ErrEventTooLarge, got %v", err)
+	}
+}
+
+func TestSplitPayload_SmallPayload(t *testing.T) {
+	payload := AnalyticsEvent{
+		ClientID: "123456.7654321",
+		Events:   []EventParams{{Name: "event_one"}, {Name: "event_two"}},
+	}
+
+	batches, err := splitPayload(payload)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(batches) != 1 {
+		t.Errorf("Expected 1 batch, got %d", len(batches))
+	}
+}
+
+func TestSendEvents_DoesNotMutateInput(t *testing.T) {
+	session := Session{
+		ClientID:  "123456.7654321",
+		SessionID: "session_123",
+	}
+	events := []EventParams{
+		{Name: "event_one", Params: map[string]string{"param1": "value1"}},
+		{Name: "event_two"},
+	}
+
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(mockClient)
+
+	err := client.SendEvents(session, events, WithTimestamp(time.Unix(1609459200, 0)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(events[0].Params) != 1 {
+		t.Errorf("Expected event params to be untouched, got %v", events[0].Params)
+	}
+	if events[1].Params != nil {
+		t.Errorf("Expected nil params to stay nil, got %v", events[1].Params)
+	}
+	if events[0].TimestampMicros != 0 || events[1].TimestampMicros != 0 {
+		t.Errorf("Expected event timestamps to be untouched")
+	}
+}
+
+func TestSendEvents_SharedTemplateConcurrently(t *testing.T) {
+	template := []EventParams{
+		{Name: "view_item", Params: map[string]string{"item_id": "sku_123"}},
+	}
+
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(mockClient)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			session := Session{ClientID: "123456.7654321", SessionID: fmt.Sprintf("session_%d", i)}
+			if err := client.SendEvents(session, template); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestBuildPayload(t *testing.T) {
+	session := Session{
+		ClientID:  "123456.7654321",
+		SessionID: "session_123",
+	}
+	events := []EventParams{
+		{Name: "event_one", Params: map[string]string{"param1": "value1"}},
+	}
+	timestamp := time.Unix(1609459200, 0)
+
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(&MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			t.Error("Expected BuildPayload not to send a request")
+			return nil, errors.New("unexpected request")
+		},
+	})
+
+	payload, payloadBytes, err := client.BuildPayload(session, events, WithUserID("user_123"), WithTimestamp(timestamp))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if payload.UserID != "user_123" {
+		t.Errorf("Expected UserID 'user_123', got '%s'", payload.UserID)
+	}
+	if payload.Events[0].Params[SessionIDParam] != session.SessionID {
+		t.Errorf("Expected session_id %s, got %s", session.SessionID, payload.Events[0].Params[SessionIDParam])
+	}
+	if payload.Events[0].TimestampMicros != timestamp.UnixMicro() {
+		t.Errorf("Expected event TimestampMicros %d, got %d", timestamp.UnixMicro(), payload.Events[0].TimestampMicros)
+	}
+
+	var decoded AnalyticsEvent
+	if err := json.Unmarshal(payloadBytes, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal payload bytes: %v", err)
+	}
+	if decoded.ClientID != payload.ClientID || len(decoded.Events) != len(payload.Events) {
+		t.Errorf("Expected payload bytes to match payload, got %s", payloadBytes)
+	}
+
+	if len(events[0].Params) != 1 {
+		t.Errorf("Expected event params to be untouched, got %v", events[0].Params)
+	}
+}
+
+func TestBuildPayload_Invalid(t *testing.T) {
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+
+	_, _, err := client.BuildPayload(Session{}, []EventParams{{Name: "event_one"}})
+	if err == nil {
+		t.Error("Expected error for empty session, got nil")
+	}
+}
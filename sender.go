@@ -0,0 +1,119 @@
+package ga4m
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSendTimeout is the default timeout for sending events in the background
+	DefaultSendTimeout = 5 * time.Second
+
+	// DefaultMaxInFlight is the default number of background sends that may be in flight at once
+	DefaultMaxInFlight = 64
+)
+
+// ErrEventDropped is reported to EventSenderConfig.OnError, wrapped with the event name, when events are
+// dropped because too many are already being sent.
+var ErrEventDropped = errors.New("event dropped: too many in flight")
+
+// EventSenderConfig configures an EventSender.
+type EventSenderConfig struct {
+	// Client sends the events. Required.
+	Client *AnalyticsClient
+
+	// Timeout bounds each send, defaults to DefaultSendTimeout.
+	Timeout time.Duration
+
+	// MaxInFlight bounds the number of sends in flight at once, defaults to DefaultMaxInFlight.
+	// Events beyond the limit are dropped rather than blocking the caller.
+	MaxInFlight int
+
+	// OnError, if set, is called with errors from sending events, including ErrEventDropped.
+	OnError func(error)
+}
+
+// EventSender sends events in the background so requests are never delayed. The middleware and interceptors
+// that track requests send through one; share a caller-owned sender between them and call Wait during graceful
+// shutdown so in-flight events are not lost.
+type EventSender struct {
+	config   EventSenderConfig
+	inFlight chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewEventSender creates a new EventSender with the provided config. It panics if the Client is nil.
+func NewEventSender(config EventSenderConfig) *EventSender {
+	if config.Client == nil {
+		panic("ga4m: EventSenderConfig.Client is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultSendTimeout
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = DefaultMaxInFlight
+	}
+	return &EventSender{
+		config:   config,
+		inFlight: make(chan struct{}, config.MaxInFlight),
+	}
+}
+
+// Send sends the event with params for the session in the background, bounded by the sender's timeout and
+// in-flight limit. Values from ctx are kept but its cancellation is not, so the send outlives the request.
+func (s *EventSender) Send(ctx context.Context, session Session, name string, params map[string]string) {
+	s.SendEvents(ctx, session, []EventParams{{Name: name, Params: params}})
+}
+
+// SendEvents sends the events for the session in the background like Send, in batches of at most
+// MaxEventsPerRequest events. The batches count once against the in-flight limit.
+func (s *EventSender) SendEvents(ctx context.Context, session Session, events []EventParams) {
+	if len(events) == 0 {
+		return
+	}
+
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		s.reportError(droppedError(events))
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.inFlight }()
+
+		ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+		for start := 0; start < len(events); start += MaxEventsPerRequest {
+			end := min(start+MaxEventsPerRequest, len(events))
+			if err := s.config.Client.SendEvents(session, events[start:end], WithContext(ctx)); err != nil {
+				s.reportError(err)
+			}
+		}
+	}()
+}
+
+// Wait blocks until all events being sent have completed, e.g. during graceful shutdown.
+func (s *EventSender) Wait() {
+	s.wg.Wait()
+}
+
+func (s *EventSender) reportError(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// droppedError wraps ErrEventDropped with the name of the dropped event, or the number of dropped events.
+func droppedError(events []EventParams) error {
+	if len(events) == 1 {
+		return fmt.Errorf("%w: %s", ErrEventDropped, events[0].Name)
+	}
+	return fmt.Errorf("%w: %d events", ErrEventDropped, len(events))
+}
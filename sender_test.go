@@ -0,0 +1,60 @@
+package ga4m
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSender_SendEvents(t *testing.T) {
+	client, payloads := recordingClient(t)
+	sender := NewEventSender(EventSenderConfig{Client: client})
+
+	events := make([]EventParams, MaxEventsPerRequest+1)
+	for i := range events {
+		events[i] = EventParams{Name: "search"}
+	}
+	sender.SendEvents(context.Background(), Session{ClientID: "123456.7654321"}, events)
+	sender.Wait()
+
+	require.Len(t, payloads(), 2)
+	assert.Len(t, payloads()[0].Events, MaxEventsPerRequest)
+	assert.Len(t, payloads()[1].Events, 1)
+}
+
+func TestNewEventSender_RequiresClient(t *testing.T) {
+	assert.Panics(t, func() { NewEventSender(EventSenderConfig{}) })
+	assert.Panics(t, func() { NewPageViewTracker(PageViewConfig{}) })
+}
+
+func TestEventSender_DropsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	client := NewClient("G-XXXXXXXXXX", "test_secret")
+	client.SetHTTPClient(&MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}})
+
+	var dropped []error
+	sender := NewEventSender(EventSenderConfig{
+		Client:      client,
+		MaxInFlight: 1,
+		OnError:     func(err error) { dropped = append(dropped, err) },
+	})
+
+	session := Session{ClientID: "123456.7654321"}
+	sender.Send(context.Background(), session, ExceptionEvent, nil)
+	sender.Send(context.Background(), session, ExceptionEvent, nil) // does not block
+	close(release)
+	sender.Wait()
+
+	require.Len(t, dropped, 1)
+	assert.True(t, errors.Is(dropped[0], ErrEventDropped))
+	assert.Equal(t, "event dropped: too many in flight: exception", dropped[0].Error())
+}
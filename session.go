@@ -1,16 +1,16 @@
 package ga4m
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/labstack/echo/v4"
 )
 
 const (
-	// ContextKey is the key middleware uses to store the Google Analytics session in the echo context
+	// ContextKey is the key framework middleware uses to store the Google Analytics session in the framework's
+	// context, such as echo.Context or gin.Context. Request contexts use an unexported key instead, see SessionFromContext
 	ContextKey = "ga4m.session"
 
 	// google analytics cookie names
@@ -18,6 +18,17 @@ const (
 	sessionCookieName = "_ga_"
 )
 
+// SessionCookieFormat is the layout of a _ga_* session cookie value.
+type SessionCookieFormat string
+
+const (
+	// SessionCookieGS1 is the dot-separated format, e.g. GS1.1.1731019235.1.1.1731019762.0.0.0
+	SessionCookieGS1 SessionCookieFormat = "GS1"
+
+	// SessionCookieGS2 is the dollar-delimited key/value format, e.g. GS2.1.s1731019235$o1$g1$t1731019762$j0$l0$h0
+	SessionCookieGS2 SessionCookieFormat = "GS2"
+)
+
 // EmptySession is an empty Google Analytics session
 var EmptySession = Session{}
 
@@ -37,10 +48,53 @@ type Session struct {
 	HitCount       int       // Number of hits/interactions in the current session
 	IsFirstSession bool      // Indicates if this is the user's first session
 	IsNewSession   bool      // Indicates if this is a new session
+	SessionExtra   string    // Unrecognised GS2 segments, dollar-delimited and kept verbatim (e.g., "d1a2b3")
+}
+
+// ClientCookieValue returns the _ga cookie value for the session, e.g. GA1.1.476555468.1726969270.
+func (s Session) ClientCookieValue() string {
+	version := s.ClientVersion
+	if version == "" {
+		version = "1"
+	}
+	return "GA1." + version + "." + s.ClientID
+}
+
+// SessionCookieValue returns the _ga_* cookie value for the session in the given format, defaulting to GS1.
+// SessionExtra is only written in the GS2 format.
+func (s Session) SessionCookieValue(format SessionCookieFormat) string {
+	version := s.SessionVersion
+	if version == "" {
+		version = "1"
+	}
+
+	if format == SessionCookieGS2 {
+		value := fmt.Sprintf("GS2.%s.s%s$o%d$g%d$t%d$j%d$l%d$h%d",
+			version, s.SessionID, s.SessionCount, boolToInt(s.IsEngaged), unixSeconds(s.LastSession),
+			s.HitCount, boolToInt(s.IsFirstSession), boolToInt(s.IsNewSession))
+		if s.SessionExtra != "" {
+			value += "$" + s.SessionExtra
+		}
+		return value
+	}
+
+	return fmt.Sprintf("GS1.%s.%s.%d.%d.%d.%d.%d.%d",
+		version, s.SessionID, s.SessionCount, boolToInt(s.IsEngaged), unixSeconds(s.LastSession),
+		s.HitCount, boolToInt(s.IsFirstSession), boolToInt(s.IsNewSession))
 }
 
 // ParseSessionFromRequest parses the Google Analytics cookies from an HTTP request and returns a Session.
+// The client ID resolved by FPIDMiddleware for the request, or else the one in an unencrypted FPID cookie with the
+// default name, is preferred over the _ga cookie's; use FPIDConfig.ParseSession for custom or encrypted FPID cookies
+// outside of FPIDMiddleware.
 func ParseSessionFromRequest(r *http.Request) Session {
+	session := parseSessionCookies(r)
+	preferFPID(r, &session)
+	return session
+}
+
+// parseSessionCookies parses the _ga cookie and the first _ga_* cookie from an HTTP request.
+func parseSessionCookies(r *http.Request) Session {
 	var clientCookieValue, sessionCookieValue string
 
 	// find _ga client cookie
@@ -60,12 +114,74 @@ func ParseSessionFromRequest(r *http.Request) Session {
 	return parseGoogleAnalyticsCookies(clientCookieValue, sessionCookieValue)
 }
 
-// ParseSessionFromEchoContext returns the Google Analytics tracking data from an echo.Context
-func ParseSessionFromEchoContext(e echo.Context) Session {
-	return ParseSessionFromRequest(e.Request())
+// ParseStreamSession parses the Google Analytics cookies for a specific GA4 stream from an HTTP request.
+// Only the session cookie belonging to the measurement ID is used, e.g. _ga_ABC123 for G-ABC123;
+// if it is absent the returned Session carries only the client cookie data.
+func ParseStreamSession(r *http.Request, measurementID string) Session {
+	var clientCookieValue, sessionCookieValue string
+
+	// find _ga client cookie
+	if cookie, err := r.Cookie(clientCookieName); err == nil {
+		clientCookieValue = cookie.Value
+	}
+
+	// find the stream's _ga_<container ID> session cookie
+	if cookie, err := r.Cookie(sessionCookieName + ContainerID(measurementID)); err == nil {
+		sessionCookieValue = cookie.Value
+	}
+
+	// parse ga client and session cookies
+	session := parseGoogleAnalyticsCookies(clientCookieValue, sessionCookieValue)
+	preferFPID(r, &session)
+	return session
+}
+
+// ParseSessionsFromRequest parses the Google Analytics cookies for every GA4 stream present in an HTTP request.
+// The returned map is keyed by container ID, the part of the _ga_* cookie name after the prefix (e.g. "ABC123").
+func ParseSessionsFromRequest(r *http.Request) map[string]Session {
+	var clientCookieValue string
+
+	// find _ga client cookie
+	if cookie, err := r.Cookie(clientCookieName); err == nil {
+		clientCookieValue = cookie.Value
+	}
+
+	// parse every _ga_* session cookie, keeping the first of any duplicates
+	sessions := make(map[string]Session)
+	for _, cookie := range r.Cookies() {
+		containerID, ok := strings.CutPrefix(cookie.Name, sessionCookieName)
+		if !ok || containerID == "" {
+			continue
+		}
+		if _, exists := sessions[containerID]; !exists {
+			session := parseGoogleAnalyticsCookies(clientCookieValue, cookie.Value)
+			preferFPID(r, &session)
+			sessions[containerID] = session
+		}
+	}
+	return sessions
+}
+
+// preferFPID replaces the session's client ID with the one resolved by FPIDMiddleware for the request or, failing
+// that, the one from a default FPID cookie, if present.
+func preferFPID(r *http.Request, session *Session) {
+	clientID, ok := fpidClientIDFromContext(r.Context())
+	if !ok {
+		clientID, ok = (FPIDConfig{}).ClientID(r)
+	}
+	if ok {
+		applyClientID(session, clientID)
+	}
+}
+
+// ContainerID returns the container ID for a measurement ID as used in _ga_* cookie names,
+// e.g. "ABC123" for "G-ABC123".
+func ContainerID(measurementID string) string {
+	return strings.TrimPrefix(measurementID, "G-")
 }
 
-// parseGoogleAnalyticsCookies parses Google Analytics cookies and returns the client ID, first visit timestamp, session count, and last session timestamp
+// parseGoogleAnalyticsCookies parses Google Analytics cookies and returns the client ID, first visit timestamp, session count, and last session timestamp.
+// Session cookies may be in either the GS1 or GS2 format.
 func parseGoogleAnalyticsCookies(client, session string) Session {
 	var data Session
 
@@ -104,57 +220,129 @@ func parseGoogleAnalyticsCookies(client, session string) Session {
 		}
 	}
 
-	// Parse GA session cookie (GS1.1.1731019235.1.1.1731019762.0.0.0)
-	// Format: GS1.1.{sessionID}.{sessionCount}.{sessionEngagement}.{timestamp}.{hitCount}.{isFirst}.{isNewSession}
+	// Parse GA session cookie (GS1 or GS2 format)
 	if session != "" {
-		parts := strings.Split(session, ".")
-		if len(parts) >= 9 && strings.HasPrefix(parts[0], "GS") {
-			// Session Version
-			if len(parts) > 1 && parts[1] != "" {
-				data.SessionVersion = parts[1]
-			}
+		if strings.HasPrefix(session, "GS2.") {
+			parseGS2SessionCookie(session, &data)
+		} else {
+			parseGS1SessionCookie(session, &data)
+		}
+	}
 
-			// Session ID - ensure not empty
-			if parts[2] != "" {
-				data.SessionID = parts[2]
-			}
+	return data
+}
+
+// parseGS1SessionCookie parses a dot-separated GS1 session cookie (GS1.1.1731019235.1.1.1731019762.0.0.0)
+// Format: GS1.1.{sessionID}.{sessionCount}.{sessionEngagement}.{timestamp}.{hitCount}.{isFirst}.{isNewSession}
+func parseGS1SessionCookie(session string, data *Session) {
+	parts := strings.Split(session, ".")
+	if len(parts) < 9 || !strings.HasPrefix(parts[0], "GS") {
+		return
+	}
+
+	// Session Version
+	if parts[1] != "" {
+		data.SessionVersion = parts[1]
+	}
+
+	// Session ID - ensure not empty
+	if parts[2] != "" {
+		data.SessionID = parts[2]
+	}
+
+	// Session count - validate non-negative
+	if count, err := strconv.Atoi(parts[3]); err == nil && count >= 0 {
+		data.SessionCount = count
+	}
+
+	// Session engagement (0 or 1)
+	if engagement, err := strconv.Atoi(parts[4]); err == nil {
+		data.IsEngaged = engagement == 1
+	}
+
+	// Timestamp of last activity - validate reasonable range
+	if ts, err := strconv.ParseInt(parts[5], 10, 64); err == nil {
+		now := time.Now().Unix()
+		if ts > 0 && ts <= now {
+			data.LastSession = time.Unix(ts, 0)
+		}
+	}
+
+	// Hit count - validate non-negative
+	if hits, err := strconv.Atoi(parts[6]); err == nil && hits >= 0 {
+		data.HitCount = hits
+	}
+
+	// Is first session (0 or 1)
+	if isFirst, err := strconv.Atoi(parts[7]); err == nil {
+		data.IsFirstSession = isFirst == 1
+	}
+
+	// Is new session (0 or 1)
+	if isNew, err := strconv.Atoi(parts[8]); err == nil {
+		data.IsNewSession = isNew == 1
+	}
+}
+
+// parseGS2SessionCookie parses a GS2 session cookie (GS2.1.s1731019235$o1$g1$t1731019762$j0$l0$h0)
+// Format: GS2.{version}.{segments} where segments are dollar-delimited and each starts with a one
+// letter key: s (session ID), o (session count), g (engagement), t (timestamp), j (hit count),
+// l (is first session) and h (is new session), matching the positions of the GS1 format.
+// Segments with unknown keys are kept verbatim in SessionExtra.
+func parseGS2SessionCookie(session string, data *Session) {
+	parts := strings.SplitN(session, ".", 3)
+	if len(parts) < 3 || parts[2] == "" {
+		return
+	}
 
-			// Session count - validate non-negative
-			if count, err := strconv.Atoi(parts[3]); err == nil && count >= 0 {
+	// Session Version
+	if parts[1] != "" {
+		data.SessionVersion = parts[1]
+	}
+
+	var extra []string
+	for _, segment := range strings.Split(parts[2], "$") {
+		if segment == "" {
+			continue
+		}
+		key, value := segment[0], segment[1:]
+		switch key {
+		case 's': // Session ID - ensure not empty
+			if value != "" {
+				data.SessionID = value
+			}
+		case 'o': // Session count - validate non-negative
+			if count, err := strconv.Atoi(value); err == nil && count >= 0 {
 				data.SessionCount = count
 			}
-
-			// Session engagement (0 or 1)
-			if engagement, err := strconv.Atoi(parts[4]); err == nil {
+		case 'g': // Session engagement (0 or 1)
+			if engagement, err := strconv.Atoi(value); err == nil {
 				data.IsEngaged = engagement == 1
 			}
-
-			// Timestamp of last activity - validate reasonable range
-			if ts, err := strconv.ParseInt(parts[5], 10, 64); err == nil {
+		case 't': // Timestamp of last activity - validate reasonable range
+			if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
 				now := time.Now().Unix()
 				if ts > 0 && ts <= now {
 					data.LastSession = time.Unix(ts, 0)
 				}
 			}
-
-			// Hit count - validate non-negative
-			if hits, err := strconv.Atoi(parts[6]); err == nil && hits >= 0 {
+		case 'j': // Hit count - validate non-negative
+			if hits, err := strconv.Atoi(value); err == nil && hits >= 0 {
 				data.HitCount = hits
 			}
-
-			// Is first session (0 or 1)
-			if isFirst, err := strconv.Atoi(parts[7]); err == nil {
+		case 'l': // Is first session (0 or 1)
+			if isFirst, err := strconv.Atoi(value); err == nil {
 				data.IsFirstSession = isFirst == 1
 			}
-
-			// Is new session (0 or 1)
-			if isNew, err := strconv.Atoi(parts[8]); err == nil {
+		case 'h': // Is new session (0 or 1)
+			if isNew, err := strconv.Atoi(value); err == nil {
 				data.IsNewSession = isNew == 1
 			}
+		default:
+			extra = append(extra, segment)
 		}
 	}
-
-	return data
+	data.SessionExtra = strings.Join(extra, "$")
 }
 
 // LatestSessions compares Google Analytics sessions and returns the latest one
@@ -170,3 +358,18 @@ func LatestSessions(sessions ...Session) Session {
 	}
 	return latest
 }
+
+// unixSeconds returns the Unix time of t, or 0 for the zero time.
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -1,9 +1,13 @@
 package ga4m
 
 import (
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"testing"
+	"testing/quick"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -199,3 +203,214 @@ func TestLatestSessions(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGoogleAnalyticsCookies_GS2(t *testing.T) {
+	tests := []struct {
+		name           string
+		sessionCookie  string
+		expectedResult Session
+	}{
+		{
+			name:          "Standard GS2 Cookie",
+			sessionCookie: "GS2.1.s1731019235$o1$g1$t1731019762$j0$l0$h0",
+			expectedResult: Session{
+				SessionID:      "1731019235",
+				SessionVersion: "1",
+				SessionCount:   1,
+				IsEngaged:      true,
+				LastSession:    time.Unix(1731019762, 0),
+			},
+		},
+		{
+			name:          "GS2 Cookie With Flags Set",
+			sessionCookie: "GS2.1.s1731019235$o3$g0$t1731019762$j12$l1$h1",
+			expectedResult: Session{
+				SessionID:      "1731019235",
+				SessionVersion: "1",
+				SessionCount:   3,
+				LastSession:    time.Unix(1731019762, 0),
+				HitCount:       12,
+				IsFirstSession: true,
+				IsNewSession:   true,
+			},
+		},
+		{
+			name:          "GS2 Cookie With Unknown Keys",
+			sessionCookie: "GS2.1.s1731019235$o2$g1$t1731019762$j0$l0$h0$dAbC.123$x9",
+			expectedResult: Session{
+				SessionID:      "1731019235",
+				SessionVersion: "1",
+				SessionCount:   2,
+				IsEngaged:      true,
+				LastSession:    time.Unix(1731019762, 0),
+				SessionExtra:   "dAbC.123$x9",
+			},
+		},
+		{
+			name:          "GS2 Cookie With Reordered And Missing Keys",
+			sessionCookie: "GS2.1.t1731019762$s1731019235",
+			expectedResult: Session{
+				SessionID:      "1731019235",
+				SessionVersion: "1",
+				LastSession:    time.Unix(1731019762, 0),
+			},
+		},
+		{
+			name:          "GS2 Cookie With Invalid Values",
+			sessionCookie: "GS2.1.s1731019235$o-1$tabc$$",
+			expectedResult: Session{
+				SessionID:      "1731019235",
+				SessionVersion: "1",
+			},
+		},
+		{
+			name:           "GS2 Cookie Without Segments",
+			sessionCookie:  "GS2.1",
+			expectedResult: EmptySession,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseGoogleAnalyticsCookies("", tt.sessionCookie)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
+
+func TestParseSessionFromRequest_GS2(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "XXXX", Value: "GS2.1.s1731019235$o5$g1$t1731019762$j0$l0$h0"})
+
+	session := ParseSessionFromRequest(req)
+
+	assert.Equal(t, "71807069.1731019235", session.ClientID)
+	assert.Equal(t, "1731019235", session.SessionID)
+	assert.Equal(t, 5, session.SessionCount)
+}
+
+func TestParseStreamSession(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "OTHER", Value: "GS1.1.1731000000.9.1.1731019762.0.0.0"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "ABC123", Value: "GS2.1.s1731019235$o2$g1$t1731019762$j0$l0$h0"})
+
+	session := ParseStreamSession(req, "G-ABC123")
+	assert.Equal(t, "71807069.1731019235", session.ClientID)
+	assert.Equal(t, "1731019235", session.SessionID)
+	assert.Equal(t, 2, session.SessionCount)
+
+	// a stream without a session cookie only gets the client data
+	session = ParseStreamSession(req, "G-MISSING")
+	assert.Equal(t, "71807069.1731019235", session.ClientID)
+	assert.Empty(t, session.SessionID)
+}
+
+func TestParseSessionsFromRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: clientCookieName, Value: "GA1.1.71807069.1731019235"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "OTHER", Value: "GS1.1.1731000000.9.1.1731019762.0.0.0"})
+	req.AddCookie(&http.Cookie{Name: sessionCookieName + "ABC123", Value: "GS2.1.s1731019235$o2$g1$t1731019762$j0$l0$h0"})
+	req.AddCookie(&http.Cookie{Name: "unrelated", Value: "value"})
+
+	sessions := ParseSessionsFromRequest(req)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "1731000000", sessions["OTHER"].SessionID)
+	assert.Equal(t, "1731019235", sessions["ABC123"].SessionID)
+	assert.Equal(t, "71807069.1731019235", sessions["ABC123"].ClientID)
+}
+
+func TestContainerID(t *testing.T) {
+	assert.Equal(t, "ABC123", ContainerID("G-ABC123"))
+	assert.Equal(t, "ABC123", ContainerID("ABC123"))
+}
+
+func TestSession_CookieValues(t *testing.T) {
+	session := Session{
+		ClientID:       "476555468.1726969270",
+		ClientVersion:  "2",
+		SessionID:      "1731019235",
+		SessionCount:   3,
+		IsEngaged:      true,
+		LastSession:    time.Unix(1731019762, 0),
+		HitCount:       7,
+		IsNewSession:   true,
+		SessionVersion: "1",
+		SessionExtra:   "dAbC$x9",
+	}
+
+	assert.Equal(t, "GA1.2.476555468.1726969270", session.ClientCookieValue())
+	assert.Equal(t, "GS1.1.1731019235.3.1.1731019762.7.0.1", session.SessionCookieValue(SessionCookieGS1))
+	assert.Equal(t, "GS2.1.s1731019235$o3$g1$t1731019762$j7$l0$h1$dAbC$x9", session.SessionCookieValue(SessionCookieGS2))
+	assert.Equal(t, session.SessionCookieValue(SessionCookieGS1), session.SessionCookieValue(""))
+	assert.Equal(t, "GS1.1..0.0.0.0.0.0", EmptySession.SessionCookieValue(SessionCookieGS1))
+}
+
+// cookieSession generates Sessions that can be represented in GA cookies.
+type cookieSession struct {
+	Session Session
+}
+
+func (cookieSession) Generate(r *rand.Rand, _ int) reflect.Value {
+	now := time.Now().Unix()
+	firstVisit := now - r.Int63n(5*365*24*3600)
+	lastSession := firstVisit + r.Int63n(now-firstVisit+1)
+
+	var extra string
+	for i := r.Intn(3); i > 0; i-- {
+		if extra != "" {
+			extra += "$"
+		}
+		extra += string(rune('a'+r.Intn(5))) + strconv.Itoa(r.Intn(1000))
+	}
+
+	return reflect.ValueOf(cookieSession{Session{
+		ClientID:       strconv.Itoa(r.Intn(1<<31-1)+1) + "." + strconv.FormatInt(firstVisit, 10),
+		ClientVersion:  strconv.Itoa(r.Intn(4) + 1),
+		FirstVisit:     time.Unix(firstVisit, 0),
+		SessionCount:   r.Intn(1000),
+		LastSession:    time.Unix(lastSession, 0),
+		SessionID:      strconv.FormatInt(lastSession-r.Int63n(1800), 10),
+		SessionVersion: strconv.Itoa(r.Intn(2) + 1),
+		IsEngaged:      r.Intn(2) == 1,
+		HitCount:       r.Intn(500),
+		IsFirstSession: r.Intn(2) == 1,
+		IsNewSession:   r.Intn(2) == 1,
+		SessionExtra:   extra,
+	}})
+}
+
+func TestSession_CookieValuesRoundTripGS1(t *testing.T) {
+	roundTrip := func(cs cookieSession) bool {
+		expected := cs.Session
+		expected.SessionExtra = "" // GS1 has no room for unknown segments
+		parsed := parseGoogleAnalyticsCookies(expected.ClientCookieValue(), expected.SessionCookieValue(SessionCookieGS1))
+		return reflect.DeepEqual(expected, parsed)
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSession_CookieValuesRoundTripGS2(t *testing.T) {
+	roundTrip := func(cs cookieSession) bool {
+		expected := cs.Session
+		parsed := parseGoogleAnalyticsCookies(expected.ClientCookieValue(), expected.SessionCookieValue(SessionCookieGS2))
+		return reflect.DeepEqual(expected, parsed)
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSession_ParsedCookiesRoundTrip(t *testing.T) {
+	for _, value := range []string{
+		"GS1.1.1731019235.1.1.1731019762.0.0.0",
+		"GS2.1.s1731019235$o1$g1$t1731019762$j0$l0$h0",
+		"GS2.1.s1731019235$o4$g0$t1731019762$j3$l1$h1$d9f2$x1",
+	} {
+		format := SessionCookieFormat(value[:3])
+		assert.Equal(t, value, parseGoogleAnalyticsCookies("", value).SessionCookieValue(format))
+	}
+}
@@ -0,0 +1,236 @@
+package ga4m
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SessionStore persists sessions for clients that have no GA cookies, such as public API and mobile backend
+// users, keyed by something stable like a user ID or API key.
+type SessionStore interface {
+	// Get returns the session stored under key and whether it was found.
+	Get(ctx context.Context, key string) (Session, bool, error)
+
+	// Set stores the session under key.
+	Set(ctx context.Context, key string, session Session) error
+
+	// Delete removes the session stored under key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// TouchStored looks up the session stored under key, records a hit at now against it (creating a new client
+// and session if none is stored), stores the result and returns the update. The lookup and store are not
+// atomic, so concurrent hits for the same key may race; the last one stored wins.
+func (l SessionLifecycle) TouchStored(ctx context.Context, store SessionStore, key string, now time.Time) (SessionUpdate, error) {
+	session, _, err := store.Get(ctx, key)
+	if err != nil {
+		return SessionUpdate{}, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	update := l.Touch(session, now)
+	if err := store.Set(ctx, key, update.Session); err != nil {
+		return SessionUpdate{}, fmt.Errorf("failed to set session: %w", err)
+	}
+	return update, nil
+}
+
+// MemoryStore is an in-memory SessionStore that expires sessions after a TTL and evicts the least recently
+// used sessions beyond its capacity. It is safe for concurrent use.
+type MemoryStore struct {
+	capacity int
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+}
+
+type memoryEntry struct {
+	key     string
+	session Session
+	expires time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore holding at most capacity sessions, each expiring ttl after it was
+// last set. A capacity or ttl of zero or less disables the respective limit.
+func NewMemoryStore(capacity int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get implements SessionStore.
+func (s *MemoryStore) Get(_ context.Context, key string) (Session, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return Session{}, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		s.remove(elem)
+		return Session{}, false, nil
+	}
+	s.lru.MoveToFront(elem)
+	return entry.session, true, nil
+}
+
+// Set implements SessionStore.
+func (s *MemoryStore) Set(_ context.Context, key string, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expires time.Time
+	if s.ttl > 0 {
+		expires = time.Now().Add(s.ttl)
+	}
+
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.session, entry.expires = session, expires
+		s.lru.MoveToFront(elem)
+		return nil
+	}
+
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key: key, session: session, expires: expires})
+	if s.capacity > 0 {
+		for s.lru.Len() > s.capacity {
+			s.remove(s.lru.Back())
+		}
+	}
+	return nil
+}
+
+// Delete implements SessionStore.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	return nil
+}
+
+// Len returns the number of sessions held, including any that have expired but not yet been evicted.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+func (s *MemoryStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.entries, elem.Value.(*memoryEntry).key)
+}
+
+// FileStore is a SessionStore that keeps each session in a JSON file in a directory. File names are derived
+// from a hash of the key, so keys such as API keys are never written to disk. It is safe for concurrent use
+// within a process.
+type FileStore struct {
+	dir string
+	ttl time.Duration
+
+	mu sync.Mutex
+}
+
+type fileEntry struct {
+	Session Session   `json:"session"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// NewFileStore creates a new FileStore in dir, creating the directory if needed. Sessions expire ttl after
+// they were last set; a ttl of zero or less disables expiry.
+func NewFileStore(dir string, ttl time.Duration) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session store directory: %w", err)
+	}
+	return &FileStore{dir: dir, ttl: ttl}, nil
+}
+
+// Get implements SessionStore.
+func (s *FileStore) Get(_ context.Context, key string) (Session, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return Session{}, false, nil
+	}
+	if err != nil {
+		return Session{}, false, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Session{}, false, fmt.Errorf("failed to decode session: %w", err)
+	}
+	if !entry.Expires.IsZero() && time.Now().After(entry.Expires) {
+		_ = os.Remove(s.path(key))
+		return Session{}, false, nil
+	}
+	return entry.Session, true, nil
+}
+
+// Set implements SessionStore.
+func (s *FileStore) Set(_ context.Context, key string, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := fileEntry{Session: session}
+	if s.ttl > 0 {
+		entry.Expires = time.Now().Add(s.ttl)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	// write to a temporary file and rename it so readers never see a partial file
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// Delete implements SessionStore.
+func (s *FileStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
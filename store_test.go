@@ -0,0 +1,136 @@
+package ga4m
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSessionStore(t *testing.T, store SessionStore) {
+	ctx := context.Background()
+	session := Session{
+		ClientID:     "476555468.1726969270",
+		SessionID:    "1731019235",
+		SessionCount: 2,
+		LastSession:  time.Unix(1731019762, 0),
+	}
+
+	_, found, err := store.Get(ctx, "user_1")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "user_1", session))
+	got, found, err := store.Get(ctx, "user_1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, session.ClientID, got.ClientID)
+	assert.Equal(t, session.SessionCount, got.SessionCount)
+	assert.True(t, session.LastSession.Equal(got.LastSession))
+
+	require.NoError(t, store.Delete(ctx, "user_1"))
+	_, found, err = store.Get(ctx, "user_1")
+	require.NoError(t, err)
+	assert.False(t, found)
+	require.NoError(t, store.Delete(ctx, "user_1"))
+}
+
+func TestMemoryStore(t *testing.T) {
+	testSessionStore(t, NewMemoryStore(10, time.Hour))
+}
+
+func TestMemoryStore_LRU(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2, 0)
+
+	require.NoError(t, store.Set(ctx, "a", Session{ClientID: "1.1"}))
+	require.NoError(t, store.Set(ctx, "b", Session{ClientID: "2.2"}))
+	_, _, _ = store.Get(ctx, "a") // a is now more recently used than b
+	require.NoError(t, store.Set(ctx, "c", Session{ClientID: "3.3"}))
+
+	assert.Equal(t, 2, store.Len())
+	_, found, _ := store.Get(ctx, "b")
+	assert.False(t, found)
+	_, found, _ = store.Get(ctx, "a")
+	assert.True(t, found)
+}
+
+func TestMemoryStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0, 10*time.Millisecond)
+
+	require.NoError(t, store.Set(ctx, "a", Session{ClientID: "1.1"}))
+	time.Sleep(20 * time.Millisecond)
+
+	_, found, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+	testSessionStore(t, store)
+}
+
+func TestFileStore_KeysAreHashed(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(context.Background(), "../secret-api-key", Session{ClientID: "1.1"}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.NotContains(t, entries[0].Name(), "secret")
+}
+
+func TestFileStore_TTL(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), 10*time.Millisecond)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(context.Background(), "a", Session{ClientID: "1.1"}))
+	time.Sleep(20 * time.Millisecond)
+
+	_, found, err := store.Get(context.Background(), "a")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestSessionLifecycle_TouchStored(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0, 0)
+	lifecycle := SessionLifecycle{}
+	start := time.Unix(1731019235, 0)
+
+	// first hit creates a client and session
+	update, err := lifecycle.TouchStored(ctx, store, "api_key_1", start)
+	require.NoError(t, err)
+	assert.True(t, update.FirstVisit)
+	assert.True(t, update.SessionStart)
+	clientID := update.Session.ClientID
+
+	// later hit in the same session keeps the identity
+	update, err = lifecycle.TouchStored(ctx, store, "api_key_1", start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, update.SessionStart)
+	assert.Equal(t, clientID, update.Session.ClientID)
+	assert.Equal(t, 2, update.Session.HitCount)
+
+	// a hit after the timeout starts a new session for the same client
+	update, err = lifecycle.TouchStored(ctx, store, "api_key_1", start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, update.SessionStart)
+	assert.Equal(t, clientID, update.Session.ClientID)
+	assert.Equal(t, 2, update.Session.SessionCount)
+
+	stored, found, err := store.Get(ctx, "api_key_1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, update.Session, stored)
+}
@@ -0,0 +1,72 @@
+package ga4m
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Transport is an http.RoundTripper that propagates the Google Analytics identity in a request's context to
+// downstream services, so they can attribute events without the browser's cookies. The session's ClientID and
+// SessionID and the user ID are set as the ClientIDHeader, SessionIDHeader and UserIDHeader headers, for
+// IdentityMiddleware or ParseSessionFromHeader to rebuild the session on the receiving side.
+//
+// Headers are only added to requests for hosts in AllowedHosts, so identifiers never leak to third parties.
+type Transport struct {
+	// Base is the RoundTripper used to send requests, defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	// AllowedHosts are the destination hosts that receive the identity headers, matched against the request
+	// URL's host name without the port. An entry starting with "*." matches any subdomain, e.g.
+	// "*.svc.cluster.local". If empty, no headers are added.
+	AllowedHosts []string
+}
+
+// NewTransport creates a new Transport sending requests with base and propagating identity to allowedHosts.
+func NewTransport(base http.RoundTripper, allowedHosts ...string) *Transport {
+	return &Transport{Base: base, AllowedHosts: allowedHosts}
+}
+
+// RoundTrip implements http.RoundTripper. The request is cloned before headers are added.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !t.allowed(req.URL.Hostname()) {
+		return base.RoundTrip(req)
+	}
+
+	session, hasSession := SessionFromContext(req.Context())
+	userID, hasUserID := UserIDFromContext(req.Context())
+	if (!hasSession || session.ClientID == "") && !hasUserID {
+		return base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	if session.ClientID != "" {
+		req.Header.Set(ClientIDHeader, session.ClientID)
+		if session.SessionID != "" {
+			req.Header.Set(SessionIDHeader, session.SessionID)
+		}
+	}
+	if hasUserID {
+		req.Header.Set(UserIDHeader, userID)
+	}
+	return base.RoundTrip(req)
+}
+
+// allowed reports whether identity headers may be sent to host.
+func (t *Transport) allowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range t.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
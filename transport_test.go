@@ -0,0 +1,119 @@
+package ga4m
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc is an http.RoundTripper calling a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport(t *testing.T) {
+	session := Session{ClientID: "476555468.1726969270", SessionID: "1731019235"}
+
+	tests := []struct {
+		name            string
+		url             string
+		ctx             context.Context
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "Allowed Host",
+			url:  "http://orders.internal:8080/orders",
+			ctx:  NewUserIDContext(NewContext(context.Background(), session), "user_42"),
+			expectedHeaders: map[string]string{
+				ClientIDHeader:  "476555468.1726969270",
+				SessionIDHeader: "1731019235",
+				UserIDHeader:    "user_42",
+			},
+		},
+		{
+			name: "Wildcard Host",
+			url:  "https://payments.svc.cluster.local/charge",
+			ctx:  NewContext(context.Background(), session),
+			expectedHeaders: map[string]string{
+				ClientIDHeader:  "476555468.1726969270",
+				SessionIDHeader: "1731019235",
+			},
+		},
+		{
+			name:            "User ID Only",
+			url:             "http://orders.internal/orders",
+			ctx:             NewUserIDContext(context.Background(), "user_42"),
+			expectedHeaders: map[string]string{UserIDHeader: "user_42"},
+		},
+		{
+			name:            "Host Not Allowed",
+			url:             "https://api.example.com/",
+			ctx:             NewUserIDContext(NewContext(context.Background(), session), "user_42"),
+			expectedHeaders: map[string]string{},
+		},
+		{
+			name:            "Wildcard Does Not Match Apex",
+			url:             "https://svc.cluster.local/",
+			ctx:             NewContext(context.Background(), session),
+			expectedHeaders: map[string]string{},
+		},
+		{
+			name:            "No Identity",
+			url:             "http://orders.internal/orders",
+			ctx:             context.Background(),
+			expectedHeaders: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent *http.Request
+			transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				sent = req
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+			}), "orders.internal", "*.svc.cluster.local")
+
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			require.NoError(t, err)
+
+			for _, header := range []string{ClientIDHeader, SessionIDHeader, UserIDHeader} {
+				assert.Equal(t, tt.expectedHeaders[header], sent.Header.Get(header), header)
+				assert.Empty(t, req.Header.Get(header), "the original request must not be modified")
+			}
+		})
+	}
+}
+
+func TestTransport_IdentityMiddleware(t *testing.T) {
+	var session Session
+	var userID string
+	downstream := httptest.NewServer(IdentityMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ = SessionFromContext(r.Context())
+		userID, _ = UserIDFromContext(r.Context())
+	})))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, "127.0.0.1")}
+	ctx := NewContext(context.Background(), Session{ClientID: "476555468.1726969270", SessionID: "1731019235"})
+	ctx = NewUserIDContext(ctx, "user_42")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downstream.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "476555468.1726969270", session.ClientID)
+	assert.Equal(t, "1731019235", session.SessionID)
+	assert.Equal(t, "user_42", userID)
+}
@@ -1,6 +1,10 @@
 package ga4m
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
 
 const (
 	maxEventNameLength  = 40
@@ -9,6 +13,55 @@ const (
 	maxEventParams      = 25
 )
 
+// paramValueLengths are the maximum value lengths of the parameters GA allows to be longer than
+// maxParamValueLength.
+var paramValueLengths = map[string]int{
+	"page_location": 1000,
+	"page_referrer": 420,
+	"page_title":    300,
+}
+
+// paramValueLength returns the maximum value length of the named parameter.
+func paramValueLength(name string) int {
+	if length, ok := paramValueLengths[name]; ok {
+		return length
+	}
+	return maxParamValueLength
+}
+
+// measurementIDPattern matches GA4 measurement IDs such as G-XXXXXXXXXX
+var measurementIDPattern = regexp.MustCompile(`^G-[A-Z0-9]+$`)
+
+func validateMeasurementID(id string) error {
+	if id == "" {
+		return fmt.Errorf("measurement ID cannot be empty")
+	}
+	if !measurementIDPattern.MatchString(id) {
+		return fmt.Errorf("measurement ID '%s' must be in the format G-XXXXXXXXXX", id)
+	}
+	return nil
+}
+
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("endpoint cannot be empty")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("endpoint '%s' must use http or https", endpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("endpoint '%s' must include a host", endpoint)
+	}
+	if u.RawQuery != "" {
+		return fmt.Errorf("endpoint '%s' must not include a query string", endpoint)
+	}
+	return nil
+}
+
 func validateEventName(name string) error {
 	if len(name) > maxEventNameLength {
 		return fmt.Errorf("event name must be %d characters or fewer", maxEventNameLength)
@@ -52,8 +105,8 @@ func validateParams(params map[string]string) error {
 			}
 		}
 
-		if len(value) > maxParamValueLength {
-			return fmt.Errorf("parameter value for '%s' exceeds maximum length of %d", name, maxParamValueLength)
+		if maxLength := paramValueLength(name); len(value) > maxLength {
+			return fmt.Errorf("parameter value for '%s' exceeds maximum length of %d", name, maxLength)
 		}
 	}
 	return nil
@@ -1,6 +1,9 @@
 package ga4m
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestValidateEventName_Valid(t *testing.T) {
 	eventName := "validEventName_123"
@@ -42,3 +45,31 @@ func TestValidateParams_Invalid(t *testing.T) {
 		t.Errorf("Expected parameters to be invalid, got no error")
 	}
 }
+
+func TestValidateParams_ValueLengths(t *testing.T) {
+	tests := []struct {
+		name  string
+		param string
+		size  int
+		valid bool
+	}{
+		{"Default Limit", "search_term", 100, true},
+		{"Default Limit Exceeded", "search_term", 101, false},
+		{"Page Location", "page_location", 1000, true},
+		{"Page Location Exceeded", "page_location", 1001, false},
+		{"Page Referrer", "page_referrer", 420, true},
+		{"Page Referrer Exceeded", "page_referrer", 421, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateParams(map[string]string{tt.param: strings.Repeat("a", tt.size)})
+			if tt.valid && err != nil {
+				t.Errorf("Expected parameter to be valid, got error: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Expected parameter to be invalid, got no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Package ga4myaml loads ga4m event schemas from YAML.
+package ga4myaml
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentstation/ga4m"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadSchema reads a schema from a YAML (.yaml, .yml) or JSON (.json) file.
+func LoadSchema(path string) (*ga4m.Schema, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+		return ParseSchema(data)
+	default:
+		return ga4m.LoadSchema(path)
+	}
+}
+
+// ParseSchema parses and checks a schema from YAML.
+func ParseSchema(data []byte) (*ga4m.Schema, error) {
+	var schema ga4m.Schema
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if err := schema.Check(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
@@ -0,0 +1,76 @@
+package ga4myaml
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentstation/ga4m"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchemaYAML = `
+events:
+  search:
+    description: A site search
+    params:
+      search_term: {type: string, required: true, max_length: 50}
+      results: {type: int}
+  purchase:
+    params:
+      currency: {required: true, enum: [USD, EUR]}
+      value: {type: number, required: true}
+      coupon_applied: {type: bool}
+`
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchemaYAML))
+	require.NoError(t, err)
+	assert.Len(t, schema.Events, 2)
+	assert.Equal(t, "A site search", schema.Events["search"].Description)
+	assert.Equal(t, ga4m.ParamSchema{Type: ga4m.ParamTypeString, Required: true, MaxLength: 50}, schema.Events["search"].Params["search_term"])
+	assert.Equal(t, []string{"USD", "EUR"}, schema.Events["purchase"].Params["currency"].Enum)
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{"No Events", "events: {}", "at least one event"},
+		{"Invalid Event Name", "events: {1bad: {}}", "must start with a letter"},
+		{"Invalid Param Name", "events: {ok: {params: {bad-name: {}}}}", "alphanumeric"},
+		{"Unknown Type", "events: {ok: {params: {p: {type: date}}}}", "unknown type"},
+		{"Enum Type Mismatch", "events: {ok: {params: {p: {type: int, enum: [a]}}}}", "is not a valid int"},
+		{"Unknown Field", "events: {ok: {parameters: {}}}", "failed to parse schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "events.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(testSchemaYAML), 0o600))
+	schema, err := LoadSchema(yamlPath)
+	require.NoError(t, err)
+	assert.Len(t, schema.Events, 2)
+
+	jsonPath := filepath.Join(dir, "events.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"events": {"search": {}}}`), 0o600))
+	schema, err = LoadSchema(jsonPath)
+	require.NoError(t, err)
+	assert.Len(t, schema.Events, 1)
+
+	_, err = LoadSchema(filepath.Join(dir, "events.toml"))
+	assert.Error(t, err)
+}